@@ -8,15 +8,30 @@ import (
 type Bot struct {
     client      *client.Client
     middlewares []Middleware
+    onError     ErrorHook
 }
 
 func NewBot(client *client.Client) *Bot {
     return &Bot{
         client:      client,
         middlewares: []Middleware{},
+        onError:     logError,
     }
 }
 
+func (bot *Bot) Client() *client.Client {
+    return bot.client
+}
+
+// OnError sets the hook receiving errors of handlers, see ReportError. Errors are logged by default.
+func (bot *Bot) OnError(hook ErrorHook) {
+    if hook == nil {
+        hook = logError
+    }
+
+    bot.onError = hook
+}
+
 func (bot *Bot) Add(middleware Middleware) {
     bot.middlewares = append(bot.middlewares, middleware)
 }
@@ -26,6 +41,9 @@ func (bot *Bot) Handle(ctx context.Context, update *client.Update) {
         ctx = context.Background()
     }
 
+    ctx = context.WithValue(ctx, clientKey{}, bot.client)
+    ctx = withErrorReporter(ctx, bot.onError)
+
     updateHandler := newMiddlewarePipe(bot.middlewares)
 
     updateHandler.Handle(ctx, update)
@@ -0,0 +1,395 @@
+package bot
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+// Telegram limits callback data to 64 bytes.
+const MaxCallbackDataLength = 64
+
+var (
+    ErrCallbackDataTooLong     = errors.New("callback data is longer than 64 bytes")
+    ErrInvalidCallbackData     = errors.New("invalid callback data")
+    ErrInvalidCallbackSign     = errors.New("invalid callback data signature")
+    ErrNoCallbackQuery         = errors.New("no callback query in the context")
+    ErrCallbackAlreadyAnswered = errors.New("callback query is already answered")
+)
+
+const callbackSeparator = ":"
+
+// signature length in bytes before base64, 48 bits are enough against forging in a 64 bytes payload.
+const callbackSignLength = 6
+
+var (
+    callbackEscaper   = strings.NewReplacer("%", "%25", callbackSeparator, "%3A")
+    callbackUnescaper = strings.NewReplacer("%3A", callbackSeparator, "%25", "%")
+)
+
+// CallbackSchema describes callback data: the prefix the route is matched by and typed fields.
+// Fields are encoded compactly: integers in base 36, booleans as 0/1, durations in seconds.
+type CallbackSchema struct {
+    Prefix string
+    Fields []ArgSpec
+}
+
+// CallbackArgs is the decoded callback data.
+type CallbackArgs struct {
+    Prefix string
+    Args
+}
+
+type callbackArgsKey struct{}
+
+// CallbackArgsFromContext returns the fields decoded by the callback route.
+func CallbackArgsFromContext(ctx context.Context) (*CallbackArgs, bool) {
+    args, ok := ctx.Value(callbackArgsKey{}).(*CallbackArgs)
+
+    return args, ok
+}
+
+// CallbackRouter encodes callback data for buttons and builds routes decoding it.
+type CallbackRouter struct {
+    client  *client.Client
+    signKey []byte
+    onError func(ctx context.Context, update *client.Update, err error)
+}
+
+type CallbackRouterOption func(*CallbackRouter)
+
+// WithCallbackSigningKey signs callback data with HMAC-SHA256, so users can't forge it.
+// The signature takes 9 bytes of the data.
+func WithCallbackSigningKey(key []byte) CallbackRouterOption {
+    return func(router *CallbackRouter) {
+        router.signKey = key
+    }
+}
+
+// WithCallbackErrorHandler handles callback queries with invalid data. By default they are passed to the next middleware.
+func WithCallbackErrorHandler(onError func(ctx context.Context, update *client.Update, err error)) CallbackRouterOption {
+    return func(router *CallbackRouter) {
+        router.onError = onError
+    }
+}
+
+// NewCallbackRouter uses the client to answer callback queries not answered by handlers.
+// If it's nil, the client of the bot handling the update is used.
+func NewCallbackRouter(apiClient *client.Client, options ...CallbackRouterOption) *CallbackRouter {
+    router := &CallbackRouter{
+        client: apiClient,
+    }
+
+    for _, option := range options {
+        option(router)
+    }
+
+    return router
+}
+
+// Encode returns callback data with the values of the schema fields in order.
+// Values are string, int, int64, float64, bool or time.Duration according to the field types.
+func (router *CallbackRouter) Encode(schema CallbackSchema, values ...interface{}) (string, error) {
+    if len(values) > len(schema.Fields) {
+        return "", &ArgError{Arg: schema.Prefix, Err: ErrTooManyArgs}
+    }
+
+    parts := []string{schema.Prefix}
+
+    for i, value := range values {
+        part, err := encodeCallbackField(schema.Fields[i], value)
+        if err != nil {
+            return "", err
+        }
+
+        parts = append(parts, part)
+    }
+
+    data := strings.Join(parts, callbackSeparator)
+
+    if router.signKey != nil {
+        data += callbackSeparator + router.sign(data)
+    }
+
+    if len(data) > MaxCallbackDataLength {
+        return "", ErrCallbackDataTooLong
+    }
+
+    return data, nil
+}
+
+// Button returns the inline keyboard button with the encoded callback data.
+func (router *CallbackRouter) Button(text string, schema CallbackSchema, values ...interface{}) (client.InlineKeyboardButton, error) {
+    data, err := router.Encode(schema, values...)
+    if err != nil {
+        return client.InlineKeyboardButton{}, err
+    }
+
+    return client.InlineKeyboardButton{
+        Text:         text,
+        CallbackData: client.OptionalString(data),
+    }, nil
+}
+
+// Decode checks the signature and decodes the fields of the callback data.
+func (router *CallbackRouter) Decode(schema CallbackSchema, data string) (*CallbackArgs, error) {
+    parts := strings.Split(data, callbackSeparator)
+
+    if router.signKey != nil {
+        if len(parts) < 2 {
+            return nil, ErrInvalidCallbackSign
+        }
+
+        sign := parts[len(parts)-1]
+        parts = parts[:len(parts)-1]
+
+        if !hmac.Equal([]byte(sign), []byte(router.sign(strings.Join(parts, callbackSeparator)))) {
+            return nil, ErrInvalidCallbackSign
+        }
+    }
+
+    if parts[0] != schema.Prefix {
+        return nil, ErrInvalidCallbackData
+    }
+
+    fields := parts[1:]
+    if len(fields) > len(schema.Fields) {
+        return nil, &ArgError{Arg: schema.Prefix, Err: ErrTooManyArgs}
+    }
+
+    args := &CallbackArgs{
+        Prefix: schema.Prefix,
+        Args:   Args{values: make(map[string]interface{})},
+    }
+
+    for i, spec := range schema.Fields {
+        if i >= len(fields) {
+            if spec.Required {
+                return nil, &ArgError{Arg: spec.Name, Err: ErrMissingArg}
+            }
+            continue
+        }
+
+        value, err := decodeCallbackField(spec, fields[i])
+        if err != nil {
+            return nil, err
+        }
+
+        args.values[spec.Name] = value
+    }
+
+    return args, nil
+}
+
+func (router *CallbackRouter) sign(data string) string {
+    mac := hmac.New(sha256.New, router.signKey)
+    mac.Write([]byte(data))
+
+    return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignLength])
+}
+
+// NewRoute matches callback queries with the schema prefix and passes the decoded fields to the handler via the context.
+// If the handler doesn't answer the query with AnswerCallback, it's answered with no notification after the handler returns,
+// unless the handler passes the update on with updateHandler or NextRoute: the next handler answers it then.
+func (router *CallbackRouter) NewRoute(schema CallbackSchema, handler Middleware) *Route {
+    return NewRoute(CallbackPrefixMatcher(schema.Prefix), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        data := ""
+        if update.CallbackQuery.Data != nil {
+            data = *update.CallbackQuery.Data
+        }
+
+        args, err := router.Decode(schema, data)
+        if err != nil {
+            if router.onError != nil {
+                router.onError(ctx, update, err)
+                return
+            }
+
+            updateHandler(ctx, update)
+            return
+        }
+
+        apiClient := router.client
+        if apiClient == nil {
+            apiClient, _ = ClientFromContext(ctx)
+        }
+
+        answer := &callbackAnswer{
+            client:          apiClient,
+            callbackQueryId: update.CallbackQuery.Id,
+        }
+
+        ctx = context.WithValue(ctx, callbackArgsKey{}, args)
+        ctx = context.WithValue(ctx, callbackAnswerKey{}, answer)
+
+        passed := false
+
+        nextRoute, ok := ctx.Value(nextRouteKey{}).(updates.UpdateHandler)
+        if ok {
+            ctx = context.WithValue(ctx, nextRouteKey{}, updates.UpdateHandler(func(ctx context.Context, update *client.Update) {
+                passed = true
+                nextRoute(ctx, update)
+            }))
+        }
+
+        handler(ctx, update, func(ctx context.Context, update *client.Update) {
+            passed = true
+            updateHandler(ctx, update)
+        })
+
+        if passed {
+            return
+        }
+
+        // the answer only stops the loading indicator on the button, the error is not actionable
+        answer.answer(ctx, &client.AnswerCallbackQueryRequest{})
+    })
+}
+
+// CallbackPrefixMatcher matches callback queries with the data of the prefix.
+func CallbackPrefixMatcher(prefix string) RouteMatcher {
+    return func(update *client.Update) bool {
+        if update.CallbackQuery == nil || update.CallbackQuery.Data == nil {
+            return false
+        }
+
+        data := *update.CallbackQuery.Data
+
+        return data == prefix || strings.HasPrefix(data, prefix+callbackSeparator)
+    }
+}
+
+type callbackAnswer struct {
+    client          *client.Client
+    callbackQueryId string
+    mu              sync.Mutex
+    answered        bool
+}
+
+type callbackAnswerKey struct{}
+
+func (answer *callbackAnswer) answer(ctx context.Context, req *client.AnswerCallbackQueryRequest) error {
+    answer.mu.Lock()
+    defer answer.mu.Unlock()
+
+    if answer.answered {
+        return ErrCallbackAlreadyAnswered
+    }
+
+    if answer.client == nil {
+        return ErrNoClient
+    }
+
+    answer.answered = true
+
+    req.CallbackQueryId = answer.callbackQueryId
+
+    _, err := answer.client.AnswerCallbackQueryCtx(ctx, req)
+
+    return err
+}
+
+// AnswerCallback answers the callback query of the callback route. CallbackQueryId is filled, req may be nil.
+func AnswerCallback(ctx context.Context, req *client.AnswerCallbackQueryRequest) error {
+    answer, ok := ctx.Value(callbackAnswerKey{}).(*callbackAnswer)
+    if !ok {
+        return ErrNoCallbackQuery
+    }
+
+    if req == nil {
+        req = &client.AnswerCallbackQueryRequest{}
+    }
+
+    return answer.answer(ctx, req)
+}
+
+func encodeCallbackField(spec ArgSpec, value interface{}) (string, error) {
+    switch spec.Type {
+    case ArgInt:
+        switch value := value.(type) {
+        case int:
+            return strconv.FormatInt(int64(value), 36), nil
+        case int64:
+            return strconv.FormatInt(value, 36), nil
+        }
+
+    case ArgFloat:
+        value, ok := value.(float64)
+        if ok {
+            return strconv.FormatFloat(value, 'g', -1, 64), nil
+        }
+
+    case ArgBool:
+        value, ok := value.(bool)
+        if ok && value {
+            return "1", nil
+        }
+        if ok {
+            return "0", nil
+        }
+
+    case ArgDuration:
+        value, ok := value.(time.Duration)
+        if ok {
+            return strconv.FormatInt(int64(value/time.Second), 36), nil
+        }
+
+    default:
+        value, ok := value.(string)
+        if ok {
+            return callbackEscaper.Replace(normalizeField(spec, value)), nil
+        }
+    }
+
+    return "", &ArgError{Arg: spec.Name, Value: fmt.Sprint(value), Err: ErrInvalidArg}
+}
+
+func normalizeField(spec ArgSpec, value string) string {
+    if spec.Type == ArgMention {
+        return normalizeMention(value)
+    }
+
+    return value
+}
+
+func decodeCallbackField(spec ArgSpec, field string) (interface{}, error) {
+    var value interface{}
+    var err error
+
+    switch spec.Type {
+    case ArgInt:
+        value, err = strconv.ParseInt(field, 36, 64)
+
+    case ArgFloat:
+        value, err = strconv.ParseFloat(field, 64)
+
+    case ArgBool:
+        value, err = field == "1", nil
+        if field != "0" && field != "1" {
+            err = ErrInvalidArg
+        }
+
+    case ArgDuration:
+        var seconds int64
+        seconds, err = strconv.ParseInt(field, 36, 64)
+        value = time.Duration(seconds) * time.Second
+
+    default:
+        value = callbackUnescaper.Replace(field)
+    }
+
+    if err != nil {
+        return nil, &ArgError{Arg: spec.Name, Value: field, Err: ErrInvalidArg}
+    }
+
+    return value, nil
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+    "github.com/zelenin/grabot/updates"
+)
+
+var testCallbackSchema = CallbackSchema{
+    Prefix: "ban",
+    Fields: []ArgSpec{
+        {Name: "user", Type: ArgInt, Required: true},
+        {Name: "for", Type: ArgDuration},
+        {Name: "silent", Type: ArgBool},
+        {Name: "reason", Type: ArgString},
+    },
+}
+
+func TestCallbackEncodeDecode(t *testing.T) {
+    router := NewCallbackRouter(nil)
+
+    data, err := router.Encode(testCallbackSchema, int64(123456789), 2*time.Hour, true, "spam: 100% sure")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if strings.Count(data, callbackSeparator) != 4 {
+        t.Fatalf("the separator in the value isn't escaped: %s", data)
+    }
+
+    args, err := router.Decode(testCallbackSchema, data)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if args.Int("user") != 123456789 || args.Duration("for") != 2*time.Hour || !args.Bool("silent") || args.String("reason") != "spam: 100% sure" {
+        t.Fatalf("wrong args: %+v", args)
+    }
+
+    // omitted optional fields
+    args, err = router.Decode(testCallbackSchema, "ban:1")
+    if err != nil || args.Int("user") != 1 || args.Has("reason") {
+        t.Fatalf("wrong args: %+v, %v", args, err)
+    }
+
+    _, err = router.Decode(testCallbackSchema, "ban")
+    if !errors.Is(err, ErrMissingArg) {
+        t.Fatalf("%v, ErrMissingArg expected", err)
+    }
+
+    _, err = router.Decode(testCallbackSchema, "kick:1")
+    if err != ErrInvalidCallbackData {
+        t.Fatalf("%v, ErrInvalidCallbackData expected", err)
+    }
+
+    _, err = router.Encode(testCallbackSchema, int64(1), time.Hour, false, strings.Repeat("x", MaxCallbackDataLength))
+    if err != ErrCallbackDataTooLong {
+        t.Fatalf("%v, ErrCallbackDataTooLong expected", err)
+    }
+}
+
+func TestCallbackSignature(t *testing.T) {
+    router := NewCallbackRouter(nil, WithCallbackSigningKey([]byte("key")))
+
+    data, err := router.Encode(testCallbackSchema, int64(1))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    _, err = router.Decode(testCallbackSchema, data)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for _, forged := range []string{
+        strings.Replace(data, "ban:1:", "ban:2:", 1),
+        "ban:1",
+        "ban",
+    } {
+        _, err = router.Decode(testCallbackSchema, forged)
+        if err != ErrInvalidCallbackSign {
+            t.Errorf("%s: %v, ErrInvalidCallbackSign expected", forged, err)
+        }
+    }
+
+    _, err = NewCallbackRouter(nil, WithCallbackSigningKey([]byte("other"))).Decode(testCallbackSchema, data)
+    if err != ErrInvalidCallbackSign {
+        t.Fatalf("%v, ErrInvalidCallbackSign expected for another key", err)
+    }
+}
+
+func TestCallbackAutoAnswer(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+
+    callbackRouter := NewCallbackRouter(nil)
+
+    router := NewRouter()
+    router.AddRoute(callbackRouter.NewRoute(testCallbackSchema, func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        args, _ := CallbackArgsFromContext(ctx)
+        if args.Int("user") == 2 {
+            NextRoute(ctx, update)
+        }
+    }))
+
+    answeredByNext := false
+    router.AddRoute(NewRoute(CallbackPrefixMatcher("ban"), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        answeredByNext = AnswerCallback(ctx, &client.AnswerCallbackQueryRequest{Text: client.OptionalString("next")}) == nil
+    }))
+
+    grabot := NewBot(server.Client())
+    grabot.Add(NewRouteMiddleware(router))
+
+    // the client of the bot answers the query
+    handled := server.AddCallbackQuery(user, nil, "ban:1")
+    grabot.Handle(context.Background(), handled)
+
+    answer, _ := server.CallbackAnswer(handled.CallbackQuery.Id)
+    if !answer.Answered {
+        t.Fatal("the callback query isn't answered")
+    }
+
+    // the query passed on is answered by the next route
+    passed := server.AddCallbackQuery(user, nil, "ban:2")
+    grabot.Handle(context.Background(), passed)
+
+    answer, _ = server.CallbackAnswer(passed.CallbackQuery.Id)
+    if !answeredByNext || answer.Text == nil || *answer.Text != "next" {
+        t.Fatalf("the next route should answer the query: %+v", answer)
+    }
+}
@@ -0,0 +1,387 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+var (
+    ErrMissingArg  = errors.New("missing argument")
+    ErrInvalidArg  = errors.New("invalid argument")
+    ErrTooManyArgs = errors.New("too many arguments")
+    ErrNoCommand   = errors.New("no bot command in the message")
+)
+
+type ArgType int
+
+const (
+    ArgString ArgType = iota
+    ArgInt
+    ArgFloat
+    ArgBool
+    // Go duration or a number of days and weeks: 90s, 1h30m, 3d, 2w.
+    ArgDuration
+    // @username, stored without @.
+    ArgMention
+    // The rest of the payload, must be the last positional argument.
+    ArgRest
+)
+
+// ArgSpec describes an argument. It's given positionally or as name=value.
+type ArgSpec struct {
+    Name     string
+    Type     ArgType
+    Required bool
+}
+
+// ArgError is returned for an argument failed validation.
+type ArgError struct {
+    Arg   string
+    Value string
+    Err   error
+}
+
+func (err *ArgError) Error() string {
+    if err.Value == "" {
+        return fmt.Sprintf("%s: %s", err.Err, err.Arg)
+    }
+
+    return fmt.Sprintf("%s: %s=%q", err.Err, err.Arg, err.Value)
+}
+
+func (err *ArgError) Unwrap() error {
+    return err.Err
+}
+
+// Args are typed values of parsed arguments. Getters return zero values for missing arguments.
+type Args struct {
+    values map[string]interface{}
+}
+
+func (args *Args) Has(name string) bool {
+    _, ok := args.values[name]
+
+    return ok
+}
+
+func (args *Args) String(name string) string {
+    value, _ := args.values[name].(string)
+
+    return value
+}
+
+func (args *Args) Int(name string) int64 {
+    value, _ := args.values[name].(int64)
+
+    return value
+}
+
+func (args *Args) Float(name string) float64 {
+    value, _ := args.values[name].(float64)
+
+    return value
+}
+
+func (args *Args) Bool(name string) bool {
+    value, _ := args.values[name].(bool)
+
+    return value
+}
+
+func (args *Args) Duration(name string) time.Duration {
+    value, _ := args.values[name].(time.Duration)
+
+    return value
+}
+
+// CommandArgs is the parsed command.
+type CommandArgs struct {
+    Command string
+    // Text after the command, e.g. the deep link parameter of /start.
+    Payload string
+    // Tokens of the payload. Double quotes group words: /note "buy milk".
+    Tokens []string
+    Args
+}
+
+type commandArgsKey struct{}
+
+// CommandArgsFromContext returns the arguments parsed by the command route.
+func CommandArgsFromContext(ctx context.Context) (*CommandArgs, bool) {
+    args, ok := ctx.Value(commandArgsKey{}).(*CommandArgs)
+
+    return args, ok
+}
+
+// StartPayload returns the deep link parameter of the /start command: https://t.me/<bot>?start=<payload>.
+func StartPayload(ctx context.Context) string {
+    args, ok := CommandArgsFromContext(ctx)
+    if !ok || args.Command != "start" {
+        return ""
+    }
+
+    return args.Payload
+}
+
+var deepLinkPayloadRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// NewDeepLink returns the link starting the bot with the payload. The payload is up to 64 characters A-Z, a-z, 0-9, _ and -.
+func NewDeepLink(botUsername string, payload string) (string, error) {
+    if !deepLinkPayloadRegex.MatchString(payload) {
+        return "", &ArgError{Arg: "start", Value: payload, Err: ErrInvalidArg}
+    }
+
+    return fmt.Sprintf("https://t.me/%s?start=%s", normalizeMention(botUsername), payload), nil
+}
+
+type commandRoute struct {
+    command string
+    specs   []ArgSpec
+    handler Middleware
+    onError func(ctx context.Context, update *client.Update, err error)
+}
+
+type CommandRouteOption func(*commandRoute)
+
+// WithArgsErrorHandler handles updates with invalid arguments, e.g. to reply with the usage.
+// By default such updates are passed to the next middleware.
+func WithArgsErrorHandler(onError func(ctx context.Context, update *client.Update, err error)) CommandRouteOption {
+    return func(route *commandRoute) {
+        route.onError = onError
+    }
+}
+
+// NewCommandRoute matches the command and passes the parsed arguments to the handler via the context.
+func NewCommandRoute(command string, specs []ArgSpec, handler Middleware, options ...CommandRouteOption) *Route {
+    route := &commandRoute{
+        command: command,
+        specs:   specs,
+        handler: handler,
+    }
+
+    for _, option := range options {
+        option(route)
+    }
+
+    return NewRoute(BotCommandMatcher(command), route.Handle)
+}
+
+func (route *commandRoute) Handle(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+    args, err := ParseCommandArgs(update, route.command, route.specs)
+    if err != nil {
+        if route.onError != nil {
+            route.onError(ctx, update, err)
+            return
+        }
+
+        updateHandler(ctx, update)
+        return
+    }
+
+    route.handler(context.WithValue(ctx, commandArgsKey{}, args), update, updateHandler)
+}
+
+// ParseCommandArgs finds the command in the message and parses the text after it with the specs.
+// With nil specs the payload isn't validated.
+func ParseCommandArgs(update *client.Update, command string, specs []ArgSpec) (*CommandArgs, error) {
+    command = normalizeBotCommand(command)
+
+    if update.Message == nil || update.Message.Text == nil || update.Message.Entities == nil {
+        return nil, ErrNoCommand
+    }
+
+    text := *update.Message.Text
+
+    for _, entity := range *update.Message.Entities {
+        if entity.Type != client.MessageEntityBotCommand {
+            continue
+        }
+
+        if normalizeBotCommand(substring(text, entity.Offset, entity.Length)) != command {
+            continue
+        }
+
+        end := entity.Offset + entity.Length
+        payload := strings.TrimSpace(substring(text, end, utf16Len(text)-end))
+
+        args := &CommandArgs{
+            Command: command,
+            Payload: payload,
+            Tokens:  tokenize(payload),
+            Args:    Args{values: make(map[string]interface{})},
+        }
+
+        return args, args.parse(specs)
+    }
+
+    return nil, ErrNoCommand
+}
+
+func (args *CommandArgs) parse(specs []ArgSpec) error {
+    if specs == nil {
+        return nil
+    }
+
+    specsByName := make(map[string]ArgSpec)
+    for _, spec := range specs {
+        specsByName[spec.Name] = spec
+    }
+
+    positional := []string{}
+
+    for _, token := range args.Tokens {
+        name, value, ok := strings.Cut(token, "=")
+        if ok {
+            spec, known := specsByName[name]
+            if known && spec.Type != ArgRest {
+                err := args.set(spec, value)
+                if err != nil {
+                    return err
+                }
+                continue
+            }
+        }
+
+        positional = append(positional, token)
+    }
+
+    for _, spec := range specs {
+        if args.Has(spec.Name) {
+            continue
+        }
+
+        if spec.Type == ArgRest {
+            if len(positional) > 0 {
+                args.values[spec.Name] = strings.Join(positional, " ")
+                positional = nil
+            }
+            break
+        }
+
+        if len(positional) == 0 {
+            break
+        }
+
+        err := args.set(spec, positional[0])
+        if err != nil {
+            return err
+        }
+        positional = positional[1:]
+    }
+
+    if len(positional) > 0 {
+        return &ArgError{Arg: positional[0], Err: ErrTooManyArgs}
+    }
+
+    for _, spec := range specs {
+        if spec.Required && !args.Has(spec.Name) {
+            return &ArgError{Arg: spec.Name, Err: ErrMissingArg}
+        }
+    }
+
+    return nil
+}
+
+func (args *Args) set(spec ArgSpec, value string) error {
+    var parsed interface{}
+    var err error
+
+    switch spec.Type {
+    case ArgInt:
+        parsed, err = strconv.ParseInt(value, 10, 64)
+
+    case ArgFloat:
+        parsed, err = strconv.ParseFloat(value, 64)
+
+    case ArgBool:
+        parsed, err = parseBool(value)
+
+    case ArgDuration:
+        parsed, err = parseDuration(value)
+
+    case ArgMention:
+        if !strings.HasPrefix(value, "@") || len(value) < 2 {
+            err = ErrInvalidArg
+        }
+        parsed = normalizeMention(value)
+
+    default:
+        parsed = value
+    }
+
+    if err != nil {
+        return &ArgError{Arg: spec.Name, Value: value, Err: ErrInvalidArg}
+    }
+
+    args.values[spec.Name] = parsed
+
+    return nil
+}
+
+func parseBool(value string) (bool, error) {
+    switch strings.ToLower(value) {
+    case "on", "yes", "y":
+        return true, nil
+
+    case "off", "no", "n":
+        return false, nil
+    }
+
+    return strconv.ParseBool(value)
+}
+
+func parseDuration(value string) (time.Duration, error) {
+    for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+        if strings.HasSuffix(value, suffix) {
+            count, err := strconv.ParseInt(strings.TrimSuffix(value, suffix), 10, 64)
+            if err != nil || count < 0 {
+                return 0, ErrInvalidArg
+            }
+
+            return time.Duration(count) * unit, nil
+        }
+    }
+
+    return time.ParseDuration(value)
+}
+
+// tokenize splits the payload by whitespace, double quoted parts are kept together.
+func tokenize(payload string) []string {
+    tokens := []string{}
+    token := strings.Builder{}
+    inQuotes := false
+    hasToken := false
+
+    for _, r := range payload {
+        switch {
+        case r == '"':
+            inQuotes = !inQuotes
+            hasToken = true
+
+        case unicode.IsSpace(r) && !inQuotes:
+            if hasToken {
+                tokens = append(tokens, token.String())
+                token.Reset()
+                hasToken = false
+            }
+
+        default:
+            token.WriteRune(r)
+            hasToken = true
+        }
+    }
+
+    if hasToken {
+        tokens = append(tokens, token.String())
+    }
+
+    return tokens
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+    "context"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+    "github.com/zelenin/grabot/updates"
+)
+
+func TestCommandRouteUtf16Offsets(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+
+    var args *CommandArgs
+
+    router := NewRouter()
+    router.AddRoute(NewCommandRoute("/ban", []ArgSpec{
+        {Name: "user", Type: ArgMention, Required: true},
+        {Name: "for", Type: ArgDuration},
+        {Name: "reason", Type: ArgRest},
+    }, func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        args, _ = CommandArgsFromContext(ctx)
+    }))
+
+    grabot := NewBot(server.Client())
+    grabot.Add(NewRouteMiddleware(router))
+
+    grabot.Handle(context.Background(), server.AddMessage(grabottest.NewPrivateChat(user), user, "👍 /ban @bob 2d spam 👎"))
+
+    if args == nil {
+        t.Fatal("the command isn't routed")
+    }
+
+    if args.String("user") != "bob" || args.Duration("for") != 48*time.Hour || args.String("reason") != "spam 👎" {
+        t.Fatalf("wrong args: %+v", args)
+    }
+}
+
+func TestSubstring(t *testing.T) {
+    tests := []struct {
+        text     string
+        offset   int64
+        length   int64
+        expected string
+    }{
+        {"/start", 0, 6, "/start"},
+        {"👍 /start x", 3, 6, "/start"},
+        {"👍 /start", 3, 10, "/start"},
+        {"/start", 6, 4, ""},
+        {"привет /start", 7, 6, "/start"},
+    }
+
+    for _, test := range tests {
+        actual := substring(test.text, test.offset, test.length)
+        if actual != test.expected {
+            t.Errorf("substring(%q, %d, %d) = %q, %q expected", test.text, test.offset, test.length, actual, test.expected)
+        }
+    }
+}
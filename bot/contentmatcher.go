@@ -0,0 +1,261 @@
+package bot
+
+import (
+    "github.com/zelenin/grabot/client"
+)
+
+const (
+    ChatTypePrivate    = "private"
+    ChatTypeGroup      = "group"
+    ChatTypeSupergroup = "supergroup"
+    ChatTypeChannel    = "channel"
+)
+
+type ContentType string
+
+const (
+    ContentText                  ContentType = "text"
+    ContentAudio                 ContentType = "audio"
+    ContentDocument              ContentType = "document"
+    ContentAnimation             ContentType = "animation"
+    ContentGame                  ContentType = "game"
+    ContentPhoto                 ContentType = "photo"
+    ContentSticker               ContentType = "sticker"
+    ContentVideo                 ContentType = "video"
+    ContentVoice                 ContentType = "voice"
+    ContentVideoNote             ContentType = "video_note"
+    ContentContact               ContentType = "contact"
+    ContentLocation              ContentType = "location"
+    ContentVenue                 ContentType = "venue"
+    ContentNewChatMembers        ContentType = "new_chat_members"
+    ContentLeftChatMember        ContentType = "left_chat_member"
+    ContentNewChatTitle          ContentType = "new_chat_title"
+    ContentNewChatPhoto          ContentType = "new_chat_photo"
+    ContentDeleteChatPhoto       ContentType = "delete_chat_photo"
+    ContentGroupChatCreated      ContentType = "group_chat_created"
+    ContentSupergroupChatCreated ContentType = "supergroup_chat_created"
+    ContentChannelChatCreated    ContentType = "channel_chat_created"
+    ContentMigrateToChatId       ContentType = "migrate_to_chat_id"
+    ContentMigrateFromChatId     ContentType = "migrate_from_chat_id"
+    ContentPinnedMessage         ContentType = "pinned_message"
+    ContentInvoice               ContentType = "invoice"
+    ContentSuccessfulPayment     ContentType = "successful_payment"
+    ContentConnectedWebsite      ContentType = "connected_website"
+    ContentPassportData          ContentType = "passport_data"
+)
+
+type contentCheck struct {
+    contentType ContentType
+    has         func(message *client.Message) bool
+}
+
+// contentChecks are ordered: a venue has a location, a game or a document can have a text, so specific types go first.
+var contentChecks = []contentCheck{
+    {ContentVenue, func(message *client.Message) bool { return message.Venue != nil }},
+    {ContentGame, func(message *client.Message) bool { return message.Game != nil }},
+    {ContentAnimation, func(message *client.Message) bool { return message.Animation != nil }},
+    {ContentAudio, func(message *client.Message) bool { return message.Audio != nil }},
+    {ContentDocument, func(message *client.Message) bool { return message.Document != nil }},
+    {ContentPhoto, func(message *client.Message) bool { return message.Photo != nil }},
+    {ContentSticker, func(message *client.Message) bool { return message.Sticker != nil }},
+    {ContentVideo, func(message *client.Message) bool { return message.Video != nil }},
+    {ContentVoice, func(message *client.Message) bool { return message.Voice != nil }},
+    {ContentVideoNote, func(message *client.Message) bool { return message.VideoNote != nil }},
+    {ContentContact, func(message *client.Message) bool { return message.Contact != nil }},
+    {ContentLocation, func(message *client.Message) bool { return message.Location != nil }},
+    {ContentNewChatMembers, func(message *client.Message) bool { return message.NewChatMembers != nil }},
+    {ContentLeftChatMember, func(message *client.Message) bool { return message.LeftChatMember != nil }},
+    {ContentNewChatTitle, func(message *client.Message) bool { return message.NewChatTitle != nil }},
+    {ContentNewChatPhoto, func(message *client.Message) bool { return message.NewChatPhoto != nil }},
+    {ContentDeleteChatPhoto, func(message *client.Message) bool { return message.DeleteChatPhoto != nil }},
+    {ContentGroupChatCreated, func(message *client.Message) bool { return message.GroupChatCreated != nil }},
+    {ContentSupergroupChatCreated, func(message *client.Message) bool { return message.SupergroupChatCreated != nil }},
+    {ContentChannelChatCreated, func(message *client.Message) bool { return message.ChannelChatCreated != nil }},
+    {ContentMigrateToChatId, func(message *client.Message) bool { return message.MigrateToChatId != nil }},
+    {ContentMigrateFromChatId, func(message *client.Message) bool { return message.MigrateFromChatId != nil }},
+    {ContentPinnedMessage, func(message *client.Message) bool { return message.PinnedMessage != nil }},
+    {ContentInvoice, func(message *client.Message) bool { return message.Invoice != nil }},
+    {ContentSuccessfulPayment, func(message *client.Message) bool { return message.SuccessfulPayment != nil }},
+    {ContentConnectedWebsite, func(message *client.Message) bool { return message.ConnectedWebsite != nil }},
+    {ContentPassportData, func(message *client.Message) bool { return message.PassportData != nil }},
+    {ContentText, func(message *client.Message) bool { return message.Text != nil }},
+}
+
+// ContentTypeOf returns the content type of the message or an empty string for an unknown one.
+func ContentTypeOf(message *client.Message) ContentType {
+    for _, check := range contentChecks {
+        if check.has(message) {
+            return check.contentType
+        }
+    }
+
+    return ""
+}
+
+// MessageContentMatcher matches the message of the update (a message, an edited message or a channel post) with the predicate.
+func MessageContentMatcher(predicate func(message *client.Message) bool) RouteMatcher {
+    return func(update *client.Update) bool {
+        message := client.UpdateMessage(update)
+
+        return message != nil && predicate(message)
+    }
+}
+
+// ContentTypeMatcher matches messages with any of the content types. Unlike ContentTypeOf, it checks every type:
+// a venue matches ContentLocation too.
+func ContentTypeMatcher(contentTypes ...ContentType) RouteMatcher {
+    return MessageContentMatcher(func(message *client.Message) bool {
+        for _, check := range contentChecks {
+            for _, contentType := range contentTypes {
+                if check.contentType == contentType && check.has(message) {
+                    return true
+                }
+            }
+        }
+
+        return false
+    })
+}
+
+func PhotoMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentPhoto)
+}
+
+func DocumentMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentDocument)
+}
+
+func StickerMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentSticker)
+}
+
+func LocationMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentLocation)
+}
+
+func ContactMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentContact)
+}
+
+func VoiceMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentVoice)
+}
+
+func SuccessfulPaymentMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentSuccessfulPayment)
+}
+
+func NewChatMembersMatcher() RouteMatcher {
+    return ContentTypeMatcher(ContentNewChatMembers)
+}
+
+func ForwardedMatcher() RouteMatcher {
+    return MessageContentMatcher(func(message *client.Message) bool {
+        return message.ForwardDate != nil
+    })
+}
+
+func ReplyMatcher() RouteMatcher {
+    return MessageContentMatcher(func(message *client.Message) bool {
+        return message.ReplyToMessage != nil
+    })
+}
+
+func MediaGroupMatcher() RouteMatcher {
+    return MessageContentMatcher(func(message *client.Message) bool {
+        return message.MediaGroupId != nil
+    })
+}
+
+// EntityMatcher matches messages with an entity of any of the types in the text or the caption.
+func EntityMatcher(entityTypes ...client.MessageEntityType) RouteMatcher {
+    return MessageContentMatcher(func(message *client.Message) bool {
+        for _, entities := range []*[]client.MessageEntity{message.Entities, message.CaptionEntities} {
+            if entities == nil {
+                continue
+            }
+
+            for _, entity := range *entities {
+                for _, entityType := range entityTypes {
+                    if entity.Type == entityType {
+                        return true
+                    }
+                }
+            }
+        }
+
+        return false
+    })
+}
+
+// FromMatcher matches updates sent by any of the users.
+func FromMatcher(userIds ...int64) RouteMatcher {
+    return func(update *client.Update) bool {
+        user := client.UpdateUser(update)
+        if user == nil {
+            return false
+        }
+
+        for _, userId := range userIds {
+            if user.Id == userId {
+                return true
+            }
+        }
+
+        return false
+    }
+}
+
+// ChatTypeMatcher matches updates from chats of any of the types, see ChatType* constants.
+// The chat of a callback query is the chat of its message.
+func ChatTypeMatcher(chatTypes ...string) RouteMatcher {
+    return func(update *client.Update) bool {
+        chat := client.UpdateChat(update)
+        if chat == nil {
+            return false
+        }
+
+        for _, chatType := range chatTypes {
+            if chat.Type == chatType {
+                return true
+            }
+        }
+
+        return false
+    }
+}
+
+func PrivateChatMatcher() RouteMatcher {
+    return ChatTypeMatcher(ChatTypePrivate)
+}
+
+// GroupChatMatcher matches basic groups and supergroups.
+func GroupChatMatcher() RouteMatcher {
+    return ChatTypeMatcher(ChatTypeGroup, ChatTypeSupergroup)
+}
+
+func SupergroupChatMatcher() RouteMatcher {
+    return ChatTypeMatcher(ChatTypeSupergroup)
+}
+
+func ChannelChatMatcher() RouteMatcher {
+    return ChatTypeMatcher(ChatTypeChannel)
+}
+
+// ChatMatcher matches updates from any of the chats.
+func ChatMatcher(chatIds ...int64) RouteMatcher {
+    return func(update *client.Update) bool {
+        chat := client.UpdateChat(update)
+        if chat == nil {
+            return false
+        }
+
+        for _, chatId := range chatIds {
+            if chat.Id == chatId {
+                return true
+            }
+        }
+
+        return false
+    }
+}
@@ -0,0 +1,200 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+var (
+    ErrNoClient  = errors.New("no client in the context")
+    ErrNoChat    = errors.New("no chat in the update")
+    ErrNoMessage = errors.New("no message in the update")
+)
+
+type clientKey struct{}
+
+// ClientFromContext returns the client of the bot handling the update.
+func ClientFromContext(ctx context.Context) (*client.Client, bool) {
+    apiClient, ok := ctx.Value(clientKey{}).(*client.Client)
+
+    return apiClient, ok && apiClient != nil
+}
+
+// UpdateContext is the update with the client and shortcuts for handlers.
+type UpdateContext struct {
+    context.Context
+    Update        *client.Update
+    Client        *client.Client
+    updateHandler updates.UpdateHandler
+}
+
+// NewContextMiddleware adapts the handler to a Middleware. The client is the one of the bot handling the update.
+func NewContextMiddleware(handler func(c *UpdateContext)) Middleware {
+    return func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        apiClient, _ := ClientFromContext(ctx)
+
+        handler(&UpdateContext{
+            Context:       ctx,
+            Update:        update,
+            Client:        apiClient,
+            updateHandler: updateHandler,
+        })
+    }
+}
+
+// Next passes the update to the next middleware.
+func (c *UpdateContext) Next() {
+    c.updateHandler(c.Context, c.Update)
+}
+
+// EffectiveMessage returns the message of the update: a message, an edited message, a channel post or
+// the message of a callback query.
+func (c *UpdateContext) EffectiveMessage() *client.Message {
+    message := client.UpdateMessage(c.Update)
+    if message == nil && c.Update.CallbackQuery != nil {
+        message = c.Update.CallbackQuery.Message
+    }
+
+    return message
+}
+
+// EffectiveChat returns the chat the update comes from, nil for inline queries and payments.
+func (c *UpdateContext) EffectiveChat() *client.Chat {
+    return client.UpdateChat(c.Update)
+}
+
+// EffectiveUser returns the sender of the update, nil for channel posts.
+func (c *UpdateContext) EffectiveUser() *client.User {
+    return client.UpdateUser(c.Update)
+}
+
+// Text returns the text or the caption of the message, the query of an inline query or the data of a callback query.
+func (c *UpdateContext) Text() string {
+    message := client.UpdateMessage(c.Update)
+
+    switch {
+    case message != nil && message.Text != nil:
+        return *message.Text
+    case message != nil && message.Caption != nil:
+        return *message.Caption
+    case c.Update.InlineQuery != nil:
+        return c.Update.InlineQuery.Query
+    case c.Update.CallbackQuery != nil && c.Update.CallbackQuery.Data != nil:
+        return *c.Update.CallbackQuery.Data
+    }
+
+    return ""
+}
+
+type ReplyOption func(*client.SendMessageRequest)
+
+// Quote sends the reply as a reply to the message of the update: c.Reply(text, c.Quote()).
+func (c *UpdateContext) Quote() ReplyOption {
+    return func(req *client.SendMessageRequest) {
+        message := c.EffectiveMessage()
+        if message != nil {
+            req.ReplyToMessageId = client.OptionalInt(message.MessageId)
+        }
+    }
+}
+
+// Reply sends the text to the chat of the update. Options can modify the request, e.g. set ParseMode or ReplyMarkup.
+func (c *UpdateContext) Reply(text string, options ...ReplyOption) (*client.Message, error) {
+    if c.Client == nil {
+        return nil, ErrNoClient
+    }
+
+    chat := c.EffectiveChat()
+    if chat == nil {
+        return nil, ErrNoChat
+    }
+
+    req := &client.SendMessageRequest{
+        ChatId: client.IntChatId(chat.Id),
+        Text:   text,
+    }
+
+    for _, option := range options {
+        option(req)
+    }
+
+    return c.Client.SendMessageCtx(c.Context, req)
+}
+
+// Answer answers the callback query, the text is shown as a notification if it's not empty.
+// It works with the auto-answer of callback routes.
+func (c *UpdateContext) Answer(text string) error {
+    return c.answer(text, false)
+}
+
+// AnswerAlert answers the callback query with an alert.
+func (c *UpdateContext) AnswerAlert(text string) error {
+    return c.answer(text, true)
+}
+
+func (c *UpdateContext) answer(text string, showAlert bool) error {
+    if c.Update.CallbackQuery == nil {
+        return ErrNoCallbackQuery
+    }
+
+    req := &client.AnswerCallbackQueryRequest{
+        CallbackQueryId: c.Update.CallbackQuery.Id,
+    }
+
+    if text != "" {
+        req.Text = client.OptionalString(text)
+    }
+
+    if showAlert {
+        req.ShowAlert = client.OptionalBool(true)
+    }
+
+    err := AnswerCallback(c.Context, req)
+    if err != ErrNoCallbackQuery {
+        return err
+    }
+
+    if c.Client == nil {
+        return ErrNoClient
+    }
+
+    _, err = c.Client.AnswerCallbackQueryCtx(c.Context, req)
+
+    return err
+}
+
+type EditOption func(*client.EditMessageTextRequest)
+
+// Edit changes the text of the message of the update, e.g. the message with the pressed inline button.
+// The message is nil for inline messages: the api doesn't return them.
+func (c *UpdateContext) Edit(text string, options ...EditOption) (*client.Message, error) {
+    if c.Client == nil {
+        return nil, ErrNoClient
+    }
+
+    req := &client.EditMessageTextRequest{
+        Text: text,
+    }
+
+    message := c.EffectiveMessage()
+
+    switch {
+    case c.Update.CallbackQuery != nil && c.Update.CallbackQuery.InlineMessageId != nil:
+        req.InlineMessageId = c.Update.CallbackQuery.InlineMessageId
+
+    case message != nil:
+        req.ChatId = client.IntChatId(message.Chat.Id)
+        req.MessageId = client.OptionalInt(message.MessageId)
+
+    default:
+        return nil, ErrNoMessage
+    }
+
+    for _, option := range options {
+        option(req)
+    }
+
+    return c.Client.EditMessageTextCtx(c.Context, req)
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+    "context"
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestUpdateContextEdit(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+
+    server.AddChat(chat)
+
+    sent, err := server.Client().SendMessage(&client.SendMessageRequest{
+        ChatId: client.IntChatId(chat.Id),
+        Text:   "choose",
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var edited *client.Message
+    var editErr error
+
+    grabot := NewBot(server.Client())
+    grabot.Add(NewContextMiddleware(func(c *UpdateContext) {
+        edited, editErr = c.Edit("chosen")
+    }))
+
+    grabot.Handle(context.Background(), server.AddCallbackQuery(user, sent, "data"))
+
+    if editErr != nil {
+        t.Fatal(editErr)
+    }
+
+    if edited == nil || *edited.Text != "chosen" || *server.Messages(chat.Id)[0].Text != "chosen" {
+        t.Fatalf("the message isn't edited: %+v", edited)
+    }
+
+    grabot.Handle(context.Background(), server.AddUpdate(&client.Update{
+        CallbackQuery: &client.CallbackQuery{
+            Id:              "inline",
+            From:            user,
+            InlineMessageId: client.OptionalString("inline-message"),
+            ChatInstance:    "inline",
+            Data:            client.OptionalString("data"),
+        },
+    }))
+
+    if editErr != nil {
+        t.Fatal(editErr)
+    }
+
+    if edited != nil {
+        t.Fatalf("no message expected for an inline message: %+v", edited)
+    }
+
+    calls := server.CallsTo("editMessageText")
+    if len(calls) != 2 || calls[1].Params["inline_message_id"] != "inline-message" {
+        t.Fatalf("wrong editMessageText calls: %+v", calls)
+    }
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "runtime/debug"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+// ErrorMiddleware is a Middleware returning an error, see NewErrorMiddleware.
+type ErrorMiddleware func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) error
+
+// ErrorHook receives errors of handlers. The route is the one failed, nil if the error is outside of routes.
+type ErrorHook func(ctx context.Context, update *client.Update, err error, route *Route)
+
+// PanicError is a panic recovered by RecoveryMiddleware.
+type PanicError struct {
+    Value interface{}
+    Stack []byte
+}
+
+func (err *PanicError) Error() string {
+    return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// Unwrap returns the value of the panic if it's an error.
+func (err *PanicError) Unwrap() error {
+    valueErr, _ := err.Value.(error)
+
+    return valueErr
+}
+
+// errorReporter tracks the route handling the update and passes errors to the hook of the bot.
+type errorReporter struct {
+    hook  ErrorHook
+    route *Route
+}
+
+type errorReporterKey struct{}
+
+func withErrorReporter(ctx context.Context, hook ErrorHook) context.Context {
+    return context.WithValue(ctx, errorReporterKey{}, &errorReporter{
+        hook: hook,
+    })
+}
+
+// ReportError passes the error to the OnError hook of the bot handling the update.
+func ReportError(ctx context.Context, update *client.Update, err error) {
+    reporter, ok := ctx.Value(errorReporterKey{}).(*errorReporter)
+    if !ok {
+        logError(ctx, update, err, nil)
+        return
+    }
+
+    reporter.hook(ctx, update, err, reporter.route)
+}
+
+// handleRoute runs the route as the current one. The route isn't reset on panic, so RecoveryMiddleware reports it.
+func handleRoute(ctx context.Context, route *Route, update *client.Update, updateHandler updates.UpdateHandler) {
+    reporter, ok := ctx.Value(errorReporterKey{}).(*errorReporter)
+    if !ok {
+        route.Handle(ctx, update, updateHandler)
+        return
+    }
+
+    previousRoute := reporter.route
+    reporter.route = route
+
+    route.Handle(ctx, update, updateHandler)
+
+    reporter.route = previousRoute
+}
+
+// NewErrorMiddleware adapts the handler to a Middleware, returned errors are passed to the OnError hook of the bot.
+func NewErrorMiddleware(handler ErrorMiddleware) Middleware {
+    return func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        err := handler(ctx, update, updateHandler)
+        if err != nil {
+            ReportError(ctx, update, err)
+        }
+    }
+}
+
+// RecoveryMiddleware turns panics of the next middlewares into *PanicError passed to the OnError hook of the bot.
+// Add it first.
+func RecoveryMiddleware(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+    defer func() {
+        value := recover()
+        if value != nil {
+            ReportError(ctx, update, &PanicError{
+                Value: value,
+                Stack: debug.Stack(),
+            })
+        }
+    }()
+
+    updateHandler(ctx, update)
+}
+
+func logError(ctx context.Context, update *client.Update, err error, route *Route) {
+    panicErr, ok := err.(*PanicError)
+    if ok {
+        log.Printf("update #%d: %s\n%s", update.UpdateId, panicErr, panicErr.Stack)
+        return
+    }
+
+    log.Printf("update #%d: %s", update.UpdateId, err)
+}
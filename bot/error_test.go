@@ -0,0 +1,70 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+func TestOnErrorRoute(t *testing.T) {
+    errFailed := errors.New("failed")
+
+    type reported struct {
+        err   error
+        route *Route
+    }
+
+    var reports []reported
+
+    passing := NewRoute(PrefixTextMatcher("pass").RouteMatcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        NextRoute(ctx, update)
+    })
+
+    failing := NewRoute(MessageMatcher(), NewErrorMiddleware(func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) error {
+        if *update.Message.Text == "panic" {
+            panic(errFailed)
+        }
+
+        return errFailed
+    }))
+
+    router := NewRouter()
+    router.AddRoute(passing)
+    router.AddRoute(failing)
+
+    grabot := NewBot(nil)
+    grabot.OnError(func(ctx context.Context, update *client.Update, err error, route *Route) {
+        reports = append(reports, reported{err, route})
+    })
+    grabot.Add(RecoveryMiddleware)
+    grabot.Add(NewRouteMiddleware(router))
+    grabot.Add(NewErrorMiddleware(func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) error {
+        return errFailed
+    }))
+
+    grabot.Handle(context.Background(), newTextUpdate("pass"))
+
+    if len(reports) != 1 || reports[0].route != failing || reports[0].err != errFailed {
+        t.Fatalf("the error should be reported with the failed route: %+v", reports)
+    }
+
+    reports = nil
+
+    grabot.Handle(context.Background(), newTextUpdate("panic"))
+
+    var panicErr *PanicError
+    if len(reports) != 1 || reports[0].route != failing || !errors.As(reports[0].err, &panicErr) || !errors.Is(panicErr, errFailed) {
+        t.Fatalf("the panic should be reported with the failed route: %+v", reports)
+    }
+
+    reports = nil
+
+    // not matched by the router, the update reaches the last middleware
+    grabot.Handle(context.Background(), &client.Update{UpdateId: 1})
+
+    if len(reports) != 1 || reports[0].route != nil {
+        t.Fatalf("the error outside of routes should be reported without a route: %+v", reports)
+    }
+}
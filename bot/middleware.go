@@ -17,6 +17,14 @@ func LoggingMiddleware(ctx context.Context, update *client.Update, updateHandler
 
 func NoOpMiddleware(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {}
 
+// NewDeduplicationMiddleware stops updates already seen by the deduplicator.
+// An update is marked as seen after the next middlewares handle it.
+func NewDeduplicationMiddleware(deduplicator *updates.Deduplicator) Middleware {
+    return func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        deduplicator.Handle(ctx, update, updateHandler)
+    }
+}
+
 type middlewarePipe struct {
     middlewares        []Middleware
     fallbackMiddleware Middleware
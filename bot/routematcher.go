@@ -116,22 +116,114 @@ func ShippingQueryMatcher() RouteMatcher {
     }
 }
 
+func EditedMessageMatcher() RouteMatcher {
+    return func(update *client.Update) bool {
+        return update.EditedMessage != nil
+    }
+}
+
+func ChannelPostMatcher() RouteMatcher {
+    return func(update *client.Update) bool {
+        return update.ChannelPost != nil
+    }
+}
+
+func EditedChannelPostMatcher() RouteMatcher {
+    return func(update *client.Update) bool {
+        return update.EditedChannelPost != nil
+    }
+}
+
+// UpdateTypeMatcher matches updates of any of the types.
+func UpdateTypeMatcher(updateTypes ...client.UpdateType) RouteMatcher {
+    return func(update *client.Update) bool {
+        updateType := client.UpdateTypeOf(update)
+
+        for _, expectedType := range updateTypes {
+            if updateType == expectedType {
+                return true
+            }
+        }
+
+        return false
+    }
+}
+
+// And matches if all the matchers match. Matchers are checked in order until the first mismatch.
+func And(matchers ...RouteMatcher) RouteMatcher {
+    return func(update *client.Update) bool {
+        for _, matcher := range matchers {
+            if !matcher(update) {
+                return false
+            }
+        }
+
+        return true
+    }
+}
+
+// Or matches if any of the matchers matches. Matchers are checked in order until the first match.
+func Or(matchers ...RouteMatcher) RouteMatcher {
+    return func(update *client.Update) bool {
+        for _, matcher := range matchers {
+            if matcher(update) {
+                return true
+            }
+        }
+
+        return false
+    }
+}
+
+func Not(matcher RouteMatcher) RouteMatcher {
+    return func(update *client.Update) bool {
+        return !matcher(update)
+    }
+}
+
+// substring cuts the text by the offset and the length of an entity, they are in UTF-16 code units.
 func substring(s string, offset int64, length int64) string {
     end := offset + length
 
-    var start int64
+    start := len(s)
+    stop := len(s)
+
     var i int64
-    for index, _ := range s {
-        if i == offset {
-            start = int64(index)
+    for index, r := range s {
+        if i >= offset && start == len(s) {
+            start = index
         }
-        if i == end {
-            return s[start:int64(index)]
+        if i >= end {
+            stop = index
+            break
         }
 
-        i++
+        i += utf16RuneLen(r)
+    }
+
+    if start > stop {
+        return ""
     }
-    return s[start:]
+
+    return s[start:stop]
+}
+
+func utf16Len(s string) int64 {
+    var length int64
+    for _, r := range s {
+        length += utf16RuneLen(r)
+    }
+
+    return length
+}
+
+// utf16RuneLen is the number of UTF-16 code units of the rune: runes out of the basic plane take a surrogate pair.
+func utf16RuneLen(r rune) int64 {
+    if r >= 0x10000 {
+        return 2
+    }
+
+    return 1
 }
 
 func normalizeBotCommand(botCommand string) string {
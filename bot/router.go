@@ -2,6 +2,9 @@ package bot
 
 import (
     "context"
+    "sort"
+    "strings"
+    "sync"
     "github.com/zelenin/grabot/updates"
     "github.com/zelenin/grabot/client"
 )
@@ -11,14 +14,48 @@ type routeMiddleware struct {
 }
 
 func (middleware *routeMiddleware) Process(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
-    route := middleware.router.Match(update)
+    router := middleware.router
 
-    if route != nil {
-        route.Handle(ctx, update, updateHandler)
+    // the filtered update is only for the routes, the next middleware gets the original one
+    original := update
+    addressed := router.addressed(update)
+
+    // the next middleware is outside of the routes, NextRoute must not reach them from there
+    nextHandler := updateHandler
+    updateHandler = func(ctx context.Context, update *client.Update) {
+        if update == addressed {
+            update = original
+        }
+
+        nextHandler(context.WithValue(ctx, nextRouteKey{}, nil), update)
+    }
+
+    update = addressed
+
+    routes, middlewares := router.snapshot()
+
+    dispatch := func(ctx context.Context, update *client.Update, _ updates.UpdateHandler) {
+        if router.runAll {
+            router.handleAll(ctx, update, routes, updateHandler)
+            return
+        }
+
+        router.handle(ctx, update, routes, updateHandler)
+    }
+
+    if len(middlewares) == 0 {
+        dispatch(ctx, update, updateHandler)
+        return
+    }
+
+    // middlewares of the router run only for updates it has a route for
+    route, _ := match(routes, update)
+    if route == nil {
+        updateHandler(ctx, update)
         return
     }
 
-    updateHandler(ctx, update)
+    newMiddlewarePipe(append(append([]Middleware{}, middlewares...), dispatch)).Handle(ctx, update)
 }
 
 func NewRouteMiddleware(router *Router) Middleware {
@@ -48,25 +85,309 @@ func NewRoute(matcher RouteMatcher, handler Middleware) *Route {
 }
 
 type Router struct {
-    routes []*Route
+    routes      []*Route
+    middlewares []Middleware
+    priorities  map[*Route]int
+    order       map[*Route]int
+    runAll      bool
+
+    mu                 sync.RWMutex
+    botUsername        string
+    explicitAddressing bool
+}
+
+type RouterOption func(*Router)
+
+// WithBotUsername makes the router ignore bot commands addressed to other bots, e.g. /start@OtherBot.
+// Routes get the update without such bot command entities. See also LearnBotUsername.
+func WithBotUsername(username string) RouterOption {
+    return func(router *Router) {
+        router.botUsername = normalizeMention(username)
+    }
+}
+
+// WithRunAll makes the router run all matching routes in order, not only the first one.
+// The update is passed to the next middleware once after them if any route passes it on.
+func WithRunAll() RouterOption {
+    return func(router *Router) {
+        router.runAll = true
+    }
+}
+
+// WithExplicitAddressing makes the router ignore bot commands without @username outside of private chats.
+func WithExplicitAddressing() RouterOption {
+    return func(router *Router) {
+        router.explicitAddressing = true
+    }
+}
+
+func NewRouter(options ...RouterOption) *Router {
+    router := &Router{
+        routes:     []*Route{},
+        priorities: make(map[*Route]int),
+        order:      make(map[*Route]int),
+    }
+
+    for _, option := range options {
+        option(router)
+    }
+
+    return router
+}
+
+// LearnBotUsername sets the bot username with getMe, call it before handling updates.
+func (router *Router) LearnBotUsername(ctx context.Context, apiClient *client.Client) error {
+    me, err := apiClient.GetMeCtx(ctx)
+    if err != nil {
+        return err
+    }
+
+    if me.Username == nil {
+        return nil
+    }
+
+    router.mu.Lock()
+    router.botUsername = normalizeMention(*me.Username)
+    router.mu.Unlock()
+
+    return nil
+}
+
+// addressed removes bot command entities not addressed to the bot from the message, so command matchers skip them.
+// The update is copied if it's changed.
+func (router *Router) addressed(update *client.Update) *client.Update {
+    router.mu.RLock()
+    botUsername := router.botUsername
+    router.mu.RUnlock()
+
+    if botUsername == "" && !router.explicitAddressing {
+        return update
+    }
+
+    message := update.Message
+    if message == nil || message.Text == nil || message.Entities == nil {
+        return update
+    }
+
+    private := message.Chat.Type == "private"
+
+    entities := []client.MessageEntity{}
+    for _, entity := range *message.Entities {
+        if entity.Type == client.MessageEntityBotCommand && !isAddressed(substring(*message.Text, entity.Offset, entity.Length), botUsername, router.explicitAddressing && !private) {
+            continue
+        }
+
+        entities = append(entities, entity)
+    }
+
+    if len(entities) == len(*message.Entities) {
+        return update
+    }
+
+    addressedMessage := *message
+    addressedMessage.Entities = &entities
+
+    addressedUpdate := *update
+    addressedUpdate.Message = &addressedMessage
+
+    return &addressedUpdate
 }
 
-func NewRouter() *Router {
-    return &Router{
-        routes: []*Route{},
+func isAddressed(botCommand string, botUsername string, explicit bool) bool {
+    _, username, ok := strings.Cut(botCommand, "@")
+    if !ok {
+        return !explicit
+    }
+
+    // without the known username /cmd@anybot is ambiguous: it's kept
+    if botUsername == "" {
+        return true
     }
+
+    return strings.EqualFold(username, botUsername)
 }
 
+// AddRoute adds the route with priority 0.
 func (router *Router) AddRoute(route *Route) {
+    router.AddRouteWithPriority(route, 0)
+}
+
+// AddRouteWithPriority adds the route. Routes with higher priority are matched first, routes of the same priority
+// in the order they are added.
+func (router *Router) AddRouteWithPriority(route *Route, priority int) {
+    router.mu.Lock()
+    defer router.mu.Unlock()
+
+    router.order[route] = len(router.order)
+    router.priorities[route] = priority
     router.routes = append(router.routes, route)
+
+    router.sort()
+}
+
+// SetPriority changes the priority of the added route.
+func (router *Router) SetPriority(route *Route, priority int) {
+    router.mu.Lock()
+    defer router.mu.Unlock()
+
+    _, ok := router.priorities[route]
+    if !ok {
+        return
+    }
+
+    router.priorities[route] = priority
+
+    router.sort()
+}
+
+// sort keeps the routes in the order of matching, it's copied on write as snapshots are used without the lock.
+func (router *Router) sort() {
+    routes := append([]*Route{}, router.routes...)
+
+    sort.SliceStable(routes, func(i, j int) bool {
+        if router.priorities[routes[i]] != router.priorities[routes[j]] {
+            return router.priorities[routes[i]] > router.priorities[routes[j]]
+        }
+
+        return router.order[routes[i]] < router.order[routes[j]]
+    })
+
+    router.routes = routes
+}
+
+func (router *Router) snapshot() ([]*Route, []Middleware) {
+    router.mu.RLock()
+    defer router.mu.RUnlock()
+
+    return router.routes, router.middlewares
+}
+
+// Use adds middlewares run before the routes of the router. They run only if the update matches a route,
+// so a sub-router can check permissions of its routes.
+func (router *Router) Use(middlewares ...Middleware) {
+    router.mu.Lock()
+    defer router.mu.Unlock()
+
+    router.middlewares = append(append([]Middleware{}, router.middlewares...), middlewares...)
+}
+
+// Matcher matches updates the router has a route for. Use Mount to mount the router into another one.
+func (router *Router) Matcher() RouteMatcher {
+    return func(update *client.Update) bool {
+        return router.Match(router.addressed(update)) != nil
+    }
+}
+
+// Mount adds the sub-router as a route and returns the route, e.g. to change its priority.
+// An update the sub-router passes on (no route of it handles the update, a route calls NextRoute or
+// the next middleware) goes to the next matching route of the router.
+func (router *Router) Mount(subRouter *Router) *Route {
+    subRouterMiddleware := NewRouteMiddleware(subRouter)
+
+    route := NewRoute(subRouter.Matcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        next, ok := ctx.Value(nextRouteKey{}).(updates.UpdateHandler)
+        if !ok {
+            next = updateHandler
+        }
+
+        subRouterMiddleware(ctx, update, next)
+    })
+
+    router.AddRoute(route)
+
+    return route
+}
+
+// Group mounts a new sub-router with the middlewares, add the routes of the group to it.
+func (router *Router) Group(middlewares ...Middleware) *Router {
+    group := NewRouter()
+    group.Use(middlewares...)
+
+    router.Mount(group)
+
+    return group
 }
 
+// Match returns the first matching route.
 func (router *Router) Match(update *client.Update) *Route {
-    for _, route := range router.routes {
+    routes, _ := router.snapshot()
+
+    route, _ := match(routes, update)
+
+    return route
+}
+
+// MatchAll returns the matching routes in order.
+func (router *Router) MatchAll(update *client.Update) []*Route {
+    matched := []*Route{}
+
+    routes, _ := router.snapshot()
+
+    for _, route := range routes {
         if route.matcher(update) {
-            return route
+            matched = append(matched, route)
         }
     }
 
-    return nil
+    return matched
+}
+
+// match returns the first matching route and the routes after it.
+func match(routes []*Route, update *client.Update) (*Route, []*Route) {
+    for i, route := range routes {
+        if route.matcher(update) {
+            return route, routes[i+1:]
+        }
+    }
+
+    return nil, nil
+}
+
+type nextRouteKey struct{}
+
+// handle runs the first matching route, the route may continue with the next one by NextRoute.
+func (router *Router) handle(ctx context.Context, update *client.Update, routes []*Route, updateHandler updates.UpdateHandler) {
+    route, rest := match(routes, update)
+    if route == nil {
+        updateHandler(ctx, update)
+        return
+    }
+
+    next := updates.UpdateHandler(func(ctx context.Context, update *client.Update) {
+        router.handle(ctx, update, rest, updateHandler)
+    })
+
+    handleRoute(context.WithValue(ctx, nextRouteKey{}, next), route, update, updateHandler)
+}
+
+func (router *Router) handleAll(ctx context.Context, update *client.Update, routes []*Route, updateHandler updates.UpdateHandler) {
+    passed := false
+    matched := false
+
+    ctx = context.WithValue(ctx, nextRouteKey{}, nil)
+
+    for _, route := range routes {
+        if !route.matcher(update) {
+            continue
+        }
+
+        matched = true
+
+        handleRoute(ctx, route, update, func(ctx context.Context, update *client.Update) {
+            passed = true
+        })
+    }
+
+    if passed || !matched {
+        updateHandler(ctx, update)
+    }
+}
+
+// NextRoute lets the router try the next matching route, if there is none the update is passed to the next middleware.
+// It's a no-op outside of a route handler and in the run-all mode.
+func NextRoute(ctx context.Context, update *client.Update) {
+    next, ok := ctx.Value(nextRouteKey{}).(updates.UpdateHandler)
+    if ok {
+        next(ctx, update)
+    }
 }
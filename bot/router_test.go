@@ -0,0 +1,97 @@
+package bot
+
+import (
+    "context"
+    "reflect"
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+func TestNextRouteOutsideOfRoutes(t *testing.T) {
+    calls := []string{}
+
+    router := NewRouter()
+    router.AddRoute(NewRoute(MessageMatcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "A")
+
+        updateHandler(ctx, update)
+    }))
+    router.AddRoute(NewRoute(MessageMatcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "B")
+    }))
+
+    grabot := NewBot(nil)
+    grabot.Add(NewRouteMiddleware(router))
+    grabot.Add(func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "downstream")
+
+        NextRoute(ctx, update)
+    })
+
+    grabot.Handle(context.Background(), &client.Update{Message: &client.Message{}})
+
+    if !reflect.DeepEqual(calls, []string{"A", "downstream"}) {
+        t.Fatalf("wrong calls: %v", calls)
+    }
+}
+
+func TestAddressedUpdateIsOnlyForRoutes(t *testing.T) {
+    text := "/start@OtherBot"
+    entities := []client.MessageEntity{{Type: client.MessageEntityBotCommand, Offset: 0, Length: int64(len(text))}}
+    update := &client.Update{Message: &client.Message{Text: &text, Entities: &entities, Chat: client.Chat{Type: ChatTypeGroup}}}
+
+    router := NewRouter(WithBotUsername("NameOfTheBot"))
+    router.AddRoute(NewRoute(BotCommandMatcher("/start"), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        t.Fatal("the command to another bot is routed")
+    }))
+
+    var downstream *client.Update
+
+    grabot := NewBot(nil)
+    grabot.Add(NewRouteMiddleware(router))
+    grabot.Add(func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        downstream = update
+    })
+
+    grabot.Handle(context.Background(), update)
+
+    if downstream != update || len(*downstream.Message.Entities) != 1 {
+        t.Fatalf("the next middleware gets a changed update: %#v", downstream)
+    }
+}
+
+func TestSubRouterPassesOnToNextRoute(t *testing.T) {
+    calls := []string{}
+
+    router := NewRouter()
+
+    group := router.Group(func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "mw")
+
+        updateHandler(ctx, update)
+    })
+    group.AddRoute(NewRoute(MessageMatcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "g1")
+
+        NextRoute(ctx, update)
+    }))
+
+    router.AddRouteWithPriority(NewRoute(MessageMatcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "r2")
+
+        updateHandler(ctx, update)
+    }), -1)
+
+    grabot := NewBot(nil)
+    grabot.Add(NewRouteMiddleware(router))
+    grabot.Add(func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        calls = append(calls, "down")
+    })
+
+    grabot.Handle(context.Background(), &client.Update{Message: &client.Message{}})
+
+    if !reflect.DeepEqual(calls, []string{"mw", "g1", "r2", "down"}) {
+        t.Fatalf("wrong calls: %v", calls)
+    }
+}
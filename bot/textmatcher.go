@@ -0,0 +1,218 @@
+package bot
+
+import (
+    "context"
+    "regexp"
+    "strings"
+    "unicode/utf8"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+// TextMatch is the result of a text matcher. Groups[0] is the whole match, the rest are captures.
+type TextMatch struct {
+    Text   string
+    Groups []string
+    // Named captures of a regex.
+    Named map[string]string
+}
+
+// TextMatcher matches the text or the caption of a message.
+type TextMatcher struct {
+    match  func(text string) *TextMatch
+    config *textMatcherConfig
+}
+
+type textMatcherConfig struct {
+    ignoreCase  bool
+    skipCaption bool
+}
+
+type TextMatcherOption func(*textMatcherConfig)
+
+// WithIgnoreCase matches with unicode case folding.
+func WithIgnoreCase() TextMatcherOption {
+    return func(config *textMatcherConfig) {
+        config.ignoreCase = true
+    }
+}
+
+// WithoutCaption matches only the text, not the caption of media.
+func WithoutCaption() TextMatcherOption {
+    return func(config *textMatcherConfig) {
+        config.skipCaption = true
+    }
+}
+
+func newTextMatcherConfig(options []TextMatcherOption) *textMatcherConfig {
+    config := &textMatcherConfig{}
+
+    for _, option := range options {
+        option(config)
+    }
+
+    return config
+}
+
+// RegexTextMatcher matches the regex anywhere in the text, anchor it to match the whole text. It panics on an invalid regex.
+func RegexTextMatcher(expr string, options ...TextMatcherOption) *TextMatcher {
+    config := newTextMatcherConfig(options)
+
+    if config.ignoreCase {
+        expr = "(?i)" + expr
+    }
+
+    return newRegexTextMatcher(regexp.MustCompile(expr), config)
+}
+
+// GlobTextMatcher matches the whole text with the pattern: * is any text, ? is any character. Wildcards are captured.
+func GlobTextMatcher(pattern string, options ...TextMatcherOption) *TextMatcher {
+    config := newTextMatcherConfig(options)
+
+    expr := strings.Builder{}
+    expr.WriteString("(?s)")
+    if config.ignoreCase {
+        expr.WriteString("(?i)")
+    }
+    expr.WriteString("^")
+
+    for _, r := range pattern {
+        switch r {
+        case '*':
+            expr.WriteString("(.*)")
+
+        case '?':
+            expr.WriteString("(.)")
+
+        default:
+            expr.WriteString(regexp.QuoteMeta(string(r)))
+        }
+    }
+
+    expr.WriteString("$")
+
+    return newRegexTextMatcher(regexp.MustCompile(expr.String()), config)
+}
+
+func newRegexTextMatcher(regex *regexp.Regexp, config *textMatcherConfig) *TextMatcher {
+    return config.matcher(func(text string) *TextMatch {
+        groups := regex.FindStringSubmatch(text)
+        if groups == nil {
+            return nil
+        }
+
+        named := make(map[string]string)
+        for i, name := range regex.SubexpNames() {
+            if name != "" {
+                named[name] = groups[i]
+            }
+        }
+
+        return &TextMatch{
+            Text:   text,
+            Groups: groups,
+            Named:  named,
+        }
+    })
+}
+
+// ExactTextMatcher matches the whole text, surrounding whitespace is ignored.
+func ExactTextMatcher(expected string, options ...TextMatcherOption) *TextMatcher {
+    config := newTextMatcherConfig(options)
+    expected = strings.TrimSpace(expected)
+
+    return config.matcher(func(text string) *TextMatch {
+        trimmed := strings.TrimSpace(text)
+
+        if trimmed != expected && !(config.ignoreCase && strings.EqualFold(trimmed, expected)) {
+            return nil
+        }
+
+        return &TextMatch{
+            Text:   text,
+            Groups: []string{trimmed},
+        }
+    })
+}
+
+// PrefixTextMatcher matches the text starting with the prefix. The rest of the text is captured.
+func PrefixTextMatcher(prefix string, options ...TextMatcherOption) *TextMatcher {
+    config := newTextMatcherConfig(options)
+    prefixLength := utf8.RuneCountInString(prefix)
+
+    return config.matcher(func(text string) *TextMatch {
+        end := len(text)
+        runes := 0
+        for index := range text {
+            if runes == prefixLength {
+                end = index
+                break
+            }
+            runes++
+        }
+
+        head := text[:end]
+
+        if head != prefix && !(config.ignoreCase && strings.EqualFold(head, prefix)) {
+            return nil
+        }
+
+        return &TextMatch{
+            Text:   text,
+            Groups: []string{head, text[end:]},
+        }
+    })
+}
+
+func (config *textMatcherConfig) matcher(match func(text string) *TextMatch) *TextMatcher {
+    return &TextMatcher{
+        match:  match,
+        config: config,
+    }
+}
+
+// Match matches the text of the message or, if there is no text, the caption.
+func (matcher *TextMatcher) Match(update *client.Update) *TextMatch {
+    if update.Message == nil {
+        return nil
+    }
+
+    if update.Message.Text != nil {
+        return matcher.match(*update.Message.Text)
+    }
+
+    if update.Message.Caption != nil && !matcher.config.skipCaption {
+        return matcher.match(*update.Message.Caption)
+    }
+
+    return nil
+}
+
+// RouteMatcher returns the matcher for NewRoute. Use NewTextRoute to get captures in the handler.
+func (matcher *TextMatcher) RouteMatcher() RouteMatcher {
+    return func(update *client.Update) bool {
+        return matcher.Match(update) != nil
+    }
+}
+
+type textMatchKey struct{}
+
+// TextMatchFromContext returns the match of the text route.
+func TextMatchFromContext(ctx context.Context) (*TextMatch, bool) {
+    match, ok := ctx.Value(textMatchKey{}).(*TextMatch)
+
+    return match, ok
+}
+
+// NewTextRoute matches the text or the caption of a message and passes the captures to the handler via the context.
+func NewTextRoute(matcher *TextMatcher, handler Middleware) *Route {
+    return NewRoute(matcher.RouteMatcher(), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        match := matcher.Match(update)
+        if match == nil {
+            updateHandler(ctx, update)
+            return
+        }
+
+        handler(context.WithValue(ctx, textMatchKey{}, match), update, updateHandler)
+    })
+}
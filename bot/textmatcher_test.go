@@ -0,0 +1,106 @@
+package bot
+
+import (
+    "context"
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/updates"
+)
+
+func newTextUpdate(text string) *client.Update {
+    return &client.Update{
+        UpdateId: 1,
+        Message: &client.Message{
+            MessageId: 1,
+            Chat:      client.Chat{Id: 1, Type: "private"},
+            Text:      client.OptionalString(text),
+        },
+    }
+}
+
+func TestTextMatchers(t *testing.T) {
+    tests := []struct {
+        name     string
+        matcher  *TextMatcher
+        text     string
+        expected []string
+    }{
+        {"glob", GlobTextMatcher("buy * for ?"), "buy milk for 5", []string{"buy milk for 5", "milk", "5"}},
+        {"glob multiline", GlobTextMatcher("note *"), "note a\nb", []string{"note a\nb", "a\nb"}},
+        {"glob whole text", GlobTextMatcher("buy *"), "please buy milk", nil},
+        {"glob meta", GlobTextMatcher("1+1=?"), "1+1=2", []string{"1+1=2", "2"}},
+        {"glob case", GlobTextMatcher("Buy *"), "buy milk", nil},
+        {"glob ignore case", GlobTextMatcher("Buy *", WithIgnoreCase()), "BUY milk", []string{"BUY milk", "milk"}},
+        {"glob unicode case", GlobTextMatcher("привет ?", WithIgnoreCase()), "ПРИВЕТ ж", []string{"ПРИВЕТ ж", "ж"}},
+        {"prefix", PrefixTextMatcher("remind "), "remind me", []string{"remind ", "me"}},
+        {"prefix only", PrefixTextMatcher("remind"), "remind", []string{"remind", ""}},
+        {"prefix short text", PrefixTextMatcher("remind"), "rem", nil},
+        {"prefix case", PrefixTextMatcher("Remind "), "remind me", nil},
+        {"prefix ignore case", PrefixTextMatcher("Remind ", WithIgnoreCase()), "REMIND me", []string{"REMIND ", "me"}},
+        {"prefix unicode case", PrefixTextMatcher("Ёж ", WithIgnoreCase()), "ёЖ бежит", []string{"ёЖ ", "бежит"}},
+        {"exact", ExactTextMatcher("Hello"), " Hello ", []string{"Hello"}},
+        {"exact case", ExactTextMatcher("Hello"), "hello", nil},
+        {"exact simple case folding", ExactTextMatcher("Straße", WithIgnoreCase()), "STRASSE", nil},
+        {"exact unicode case", ExactTextMatcher("Привет", WithIgnoreCase()), "пРИВЕТ", []string{"пРИВЕТ"}},
+        {"regex ignore case", RegexTextMatcher(`^hi (\w+)`, WithIgnoreCase()), "HI bob", []string{"HI bob", "bob"}},
+    }
+
+    for _, test := range tests {
+        match := test.matcher.Match(newTextUpdate(test.text))
+
+        if match == nil || test.expected == nil {
+            if (match == nil) != (test.expected == nil) {
+                t.Errorf("%s: match %+v, %q expected", test.name, match, test.expected)
+            }
+            continue
+        }
+
+        if len(match.Groups) != len(test.expected) {
+            t.Errorf("%s: groups %q, %q expected", test.name, match.Groups, test.expected)
+            continue
+        }
+
+        for i := range test.expected {
+            if match.Groups[i] != test.expected[i] {
+                t.Errorf("%s: groups %q, %q expected", test.name, match.Groups, test.expected)
+                break
+            }
+        }
+    }
+}
+
+func TestTextMatcherCaption(t *testing.T) {
+    update := &client.Update{
+        UpdateId: 1,
+        Message: &client.Message{
+            MessageId: 1,
+            Chat:      client.Chat{Id: 1, Type: "private"},
+            Caption:   client.OptionalString("cat photo"),
+        },
+    }
+
+    if PrefixTextMatcher("cat").Match(update) == nil {
+        t.Fatal("the caption isn't matched")
+    }
+
+    if PrefixTextMatcher("cat", WithoutCaption()).Match(update) != nil {
+        t.Fatal("the caption is matched WithoutCaption")
+    }
+}
+
+func TestTextRoute(t *testing.T) {
+    var match *TextMatch
+
+    router := NewRouter()
+    router.AddRoute(NewTextRoute(RegexTextMatcher(`^(?P<amount>\d+) (?P<currency>[a-z]+)$`), func(ctx context.Context, update *client.Update, updateHandler updates.UpdateHandler) {
+        match, _ = TextMatchFromContext(ctx)
+    }))
+
+    NewRouteMiddleware(router)(context.Background(), newTextUpdate("10 usd"), func(ctx context.Context, update *client.Update) {
+        t.Fatal("the text route isn't matched")
+    })
+
+    if match == nil || match.Named["amount"] != "10" || match.Named["currency"] != "usd" {
+        t.Fatalf("wrong match: %+v", match)
+    }
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+    "encoding/json"
     "strconv"
     "strings"
 )
@@ -13,7 +14,8 @@ const (
     minChannelId = -1002147483647
     maxChannelId = -1000000000000
 
-    minChatId = -2147483647
+    // basic group ids outgrew int32, they end where supergroup ids begin
+    minChatId = -999999999999
 
     maxUserId = 2147483647
 )
@@ -43,7 +45,7 @@ const (
 //
 // secret chat id in [-2.002.147.483.648, -1.997.852.516.353)
 // supergroup chat id in [-1.002.147.483.647, -1.000.000.000.000)
-// basic group chat id in [-2.147.483.647, 0)
+// basic group chat id in [-999.999.999.999, 0)
 // private chat id in (0, 2.147.483.647]
 
 type ChatId interface {
@@ -64,6 +66,10 @@ func (chatId *intChatId) String() string {
     return strconv.FormatInt(chatId.chatId, 10)
 }
 
+func (chatId *intChatId) MarshalJSON() ([]byte, error) {
+    return json.Marshal(chatId.chatId)
+}
+
 func (intChatId intChatId) IsPrivate() bool {
     return intChatId.chatId > 0 && intChatId.chatId <= maxUserId
 }
@@ -113,3 +119,7 @@ type stringChatId struct {
 func (chatId *stringChatId) String() string {
     return chatId.chatId
 }
+
+func (chatId *stringChatId) MarshalJSON() ([]byte, error) {
+    return json.Marshal(chatId.chatId)
+}
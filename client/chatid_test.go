@@ -0,0 +1,33 @@
+package client_test
+
+import (
+    "testing"
+    "github.com/zelenin/grabot/client"
+)
+
+func TestIntChatIdTypes(t *testing.T) {
+    tests := []struct {
+        chatId     int64
+        basicGroup bool
+        supergroup bool
+    }{
+        {1, false, false},
+        {-1, true, false},
+        {-2147483648, true, false},
+        {-999999999999, true, false},
+        {-1000000000000, false, false},
+        {-1000000000001, false, true},
+        {-1001234567890, false, true},
+    }
+
+    for _, test := range tests {
+        chatId := client.IntChatId(test.chatId).(interface {
+            IsBasicGroup() bool
+            IsSupergroup() bool
+        })
+
+        if chatId.IsBasicGroup() != test.basicGroup || chatId.IsSupergroup() != test.supergroup {
+            t.Errorf("%d: basic group %t, supergroup %t", test.chatId, chatId.IsBasicGroup(), chatId.IsSupergroup())
+        }
+    }
+}
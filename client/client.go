@@ -1,24 +1,30 @@
 package client
 
 import (
+    "context"
     "net/http"
     "fmt"
     "encoding/json"
     "io/ioutil"
-    "errors"
     "log"
     "github.com/mxmCherry/multipartbuilder"
     "strconv"
     "io"
     "regexp"
+    "strings"
 )
 
 const baseUrl = "https://api.telegram.org"
 
 type Client struct {
-    token      string
-    httpClient *http.Client
-    logger     *log.Logger
+    token       string
+    httpClient  *http.Client
+    logger      *log.Logger
+    baseUrl     string
+    fileBaseUrl string
+    localMode   bool
+    retryPolicy *RetryPolicy
+    migration   *migration
 }
 
 type Option func(*Client)
@@ -33,6 +39,25 @@ func WithHttpClient(httpClient *http.Client) Option {
     }
 }
 
+// WithBaseUrl points the client to another Bot API server, e.g. a self-hosted telegram-bot-api or a test stub.
+// The file download base follows it unless WithFileBaseUrl is given.
+func WithBaseUrl(baseUrl string) Option {
+    return func(client *Client) {
+        client.baseUrl = strings.TrimSuffix(baseUrl, "/")
+    }
+}
+
+func WithFileBaseUrl(fileBaseUrl string) Option {
+    return func(client *Client) {
+        client.fileBaseUrl = strings.TrimSuffix(fileBaseUrl, "/")
+    }
+}
+
+// WithLocalMode is for a self-hosted Bot API server started with --local: File.FilePath is an absolute path on its disk.
+func WithLocalMode(client *Client) {
+    client.localMode = true
+}
+
 func New(token string, options ...Option) (*Client, error) {
     if !isValidToken(token) {
         return nil, fmt.Errorf("invalid token: %s", token)
@@ -54,6 +79,14 @@ func New(token string, options ...Option) (*Client, error) {
         client.logger = NullLogger
     }
 
+    if client.baseUrl == "" {
+        client.baseUrl = baseUrl
+    }
+
+    if client.fileBaseUrl == "" {
+        client.fileBaseUrl = client.baseUrl
+    }
+
     return client, nil
 }
 
@@ -65,26 +98,36 @@ type ApiResponse struct {
     Parameters  *ResponseParameters `json:"parameters,omitempty"`
 }
 
-type ApiError struct {
-    Description string              `json:"description"`
-    ErrorCode   int64               `json:"error_code"`
-    Parameters  *ResponseParameters `json:"parameters,omitempty"`
+// isTrueResult reports if the result is true instead of an object, e.g. of editing an inline message.
+func isTrueResult(apiResp *ApiResponse) bool {
+    return string(apiResp.Result) == "true"
 }
 
-func (error *ApiError) Error() string {
-    return fmt.Sprintf("%d %s", error.ErrorCode, error.Description)
+func (client *Client) Request(method string, params map[string]interface{}) (*ApiResponse, error) {
+    return client.RequestCtx(context.Background(), method, params)
 }
 
-func newError(resp *ApiResponse) error {
-    return &ApiError{
-        Description: *resp.Description,
-        ErrorCode:   *resp.ErrorCode,
-        Parameters:  resp.Parameters,
+// RequestCtx performs the api call bound to ctx, so cancellation and deadlines abort the underlying http request.
+func (client *Client) RequestCtx(ctx context.Context, method string, params map[string]interface{}) (*ApiResponse, error) {
+    if client.migration != nil {
+        return client.migration.do(ctx, method, params, client.retryingRequest)
     }
+
+    return client.retryingRequest(ctx, method, params)
 }
 
-func (client *Client) Request(method string, params map[string]interface{}) (*ApiResponse, error) {
-    uri := fmt.Sprintf("%s/bot%s/%s", baseUrl, client.token, method)
+func (client *Client) retryingRequest(ctx context.Context, method string, params map[string]interface{}) (*ApiResponse, error) {
+    if client.retryPolicy == nil || hasStreams(params) {
+        return client.request(ctx, method, params)
+    }
+
+    return client.retryPolicy.do(ctx, method, func() (*ApiResponse, error) {
+        return client.request(ctx, method, params)
+    })
+}
+
+func (client *Client) request(ctx context.Context, method string, params map[string]interface{}) (*ApiResponse, error) {
+    uri := fmt.Sprintf("%s/bot%s/%s", client.baseUrl, client.token, method)
 
     builder := multipartbuilder.New()
 
@@ -166,7 +209,7 @@ Loop:
         defer bodyReader.Close()
     }
 
-    req, err := http.NewRequest("POST", uri, bodyReader)
+    req, err := http.NewRequestWithContext(ctx, "POST", uri, bodyReader)
     if err != nil {
         return nil, err
     }
@@ -191,7 +234,10 @@ Loop:
 
     err = json.Unmarshal(data, &apiResponse)
     if err != nil {
-        return nil, errors.New(string(data))
+        return nil, &HttpError{
+            StatusCode: resp.StatusCode,
+            Body:       data,
+        }
     }
 
     return &apiResponse, nil
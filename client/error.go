@@ -0,0 +1,158 @@
+package client
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Sentinel errors to use with errors.Is:
+//
+//     if errors.Is(err, client.ErrBotBlocked) {
+//         ...
+//     }
+var (
+    ErrBadRequest   = errors.New("bad request")
+    ErrUnauthorized = errors.New("unauthorized")
+    ErrForbidden    = errors.New("forbidden")
+    ErrNotFound     = errors.New("not found")
+
+    ErrBotBlocked            = errors.New("bot was blocked by the user")
+    ErrChatNotFound          = errors.New("chat not found")
+    ErrMessageNotModified    = errors.New("message is not modified")
+    ErrMessageToEditNotFound = errors.New("message to edit not found")
+
+    // See TooManyRequestsError for the retry duration.
+    ErrTooManyRequests = errors.New("too many requests")
+    // See MigratedError for the new chat id.
+    ErrMigrated = errors.New("group migrated to supergroup")
+)
+
+type ApiError struct {
+    Description string              `json:"description"`
+    ErrorCode   int64               `json:"error_code"`
+    Parameters  *ResponseParameters `json:"parameters,omitempty"`
+}
+
+func (error *ApiError) Error() string {
+    return fmt.Sprintf("%d %s", error.ErrorCode, error.Description)
+}
+
+func (error *ApiError) Is(target error) bool {
+    description := strings.ToLower(error.Description)
+
+    switch target {
+    case ErrBadRequest:
+        return error.ErrorCode == 400
+
+    case ErrUnauthorized:
+        return error.ErrorCode == 401
+
+    case ErrForbidden:
+        return error.ErrorCode == 403
+
+    case ErrNotFound:
+        return error.ErrorCode == 404
+
+    case ErrBotBlocked:
+        return error.ErrorCode == 403 && strings.Contains(description, "bot was blocked by the user")
+
+    case ErrChatNotFound:
+        return error.ErrorCode == 400 && strings.Contains(description, "chat not found")
+
+    case ErrMessageNotModified:
+        return error.ErrorCode == 400 && strings.Contains(description, "message is not modified")
+
+    case ErrMessageToEditNotFound:
+        return error.ErrorCode == 400 && strings.Contains(description, "message to edit not found")
+
+    case ErrTooManyRequests:
+        return error.ErrorCode == 429
+
+    case ErrMigrated:
+        return error.Parameters != nil && error.Parameters.MigrateToChatId != nil
+    }
+
+    return false
+}
+
+// As supports errors.As with *TooManyRequestsError and *MigratedError targets.
+func (error *ApiError) As(target interface{}) bool {
+    switch target := target.(type) {
+    case **TooManyRequestsError:
+        if error.ErrorCode != 429 {
+            return false
+        }
+
+        var retryAfter time.Duration
+        if error.Parameters != nil && error.Parameters.RetryAfter != nil {
+            retryAfter = time.Duration(*error.Parameters.RetryAfter) * time.Second
+        }
+
+        *target = &TooManyRequestsError{
+            ApiError:   error,
+            RetryAfter: retryAfter,
+        }
+
+        return true
+
+    case **MigratedError:
+        if error.Parameters == nil || error.Parameters.MigrateToChatId == nil {
+            return false
+        }
+
+        *target = &MigratedError{
+            ApiError:        error,
+            MigrateToChatId: *error.Parameters.MigrateToChatId,
+        }
+
+        return true
+    }
+
+    return false
+}
+
+type TooManyRequestsError struct {
+    *ApiError
+    RetryAfter time.Duration
+}
+
+func (error *TooManyRequestsError) Unwrap() error {
+    return error.ApiError
+}
+
+type MigratedError struct {
+    *ApiError
+    MigrateToChatId int64
+}
+
+func (error *MigratedError) Unwrap() error {
+    return error.ApiError
+}
+
+// HttpError is returned when the response body is not an api response, e.g. an error page of a proxy.
+type HttpError struct {
+    StatusCode int
+    Body       []byte
+}
+
+func (error *HttpError) Error() string {
+    return fmt.Sprintf("unexpected response: %d %s", error.StatusCode, string(error.Body))
+}
+
+func newError(resp *ApiResponse) error {
+    apiError := &ApiError{
+        Parameters: resp.Parameters,
+    }
+
+    if resp.Description != nil {
+        apiError.Description = *resp.Description
+    }
+
+    if resp.ErrorCode != nil {
+        apiError.ErrorCode = *resp.ErrorCode
+    }
+
+    return apiError
+}
@@ -0,0 +1,82 @@
+package client_test
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+func TestApiErrorIs(t *testing.T) {
+    migrateToChatId := int64(-1001234567890)
+
+    tests := []struct {
+        err      *client.ApiError
+        target   error
+        expected bool
+    }{
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: chat not found"}, client.ErrBadRequest, true},
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: chat not found"}, client.ErrChatNotFound, true},
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: chat not found"}, client.ErrForbidden, false},
+        {&client.ApiError{ErrorCode: 401, Description: "Unauthorized"}, client.ErrUnauthorized, true},
+        {&client.ApiError{ErrorCode: 404, Description: "Not Found"}, client.ErrNotFound, true},
+        {&client.ApiError{ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"}, client.ErrBotBlocked, true},
+        {&client.ApiError{ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"}, client.ErrForbidden, true},
+        {&client.ApiError{ErrorCode: 403, Description: "Forbidden: bot is not a member of the channel chat"}, client.ErrBotBlocked, false},
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: message is not modified: specified new message content is exactly the same"}, client.ErrMessageNotModified, true},
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: MESSAGE TO EDIT NOT FOUND"}, client.ErrMessageToEditNotFound, true},
+        {&client.ApiError{ErrorCode: 429, Description: "Too Many Requests: retry after 5"}, client.ErrTooManyRequests, true},
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: group chat was upgraded to a supergroup chat", Parameters: &client.ResponseParameters{MigrateToChatId: &migrateToChatId}}, client.ErrMigrated, true},
+        {&client.ApiError{ErrorCode: 400, Description: "Bad Request: group chat was upgraded to a supergroup chat"}, client.ErrMigrated, false},
+    }
+
+    for _, test := range tests {
+        // the error is usually wrapped by the caller
+        err := fmt.Errorf("send: %w", test.err)
+
+        if errors.Is(err, test.target) != test.expected {
+            t.Errorf("errors.Is(%q, %q) = %t", test.err, test.target, !test.expected)
+        }
+    }
+}
+
+func TestApiErrorAs(t *testing.T) {
+    retryAfter := int64(5)
+    migrateToChatId := int64(-1001234567890)
+
+    var err error = &client.ApiError{
+        ErrorCode:   429,
+        Description: "Too Many Requests: retry after 5",
+        Parameters:  &client.ResponseParameters{RetryAfter: &retryAfter},
+    }
+
+    var tooManyRequestsError *client.TooManyRequestsError
+    if !errors.As(err, &tooManyRequestsError) || tooManyRequestsError.RetryAfter != 5*time.Second {
+        t.Fatalf("TooManyRequestsError with 5s expected: %+v", tooManyRequestsError)
+    }
+
+    if !errors.Is(tooManyRequestsError, client.ErrTooManyRequests) {
+        t.Fatal("TooManyRequestsError doesn't unwrap to the api error")
+    }
+
+    var migratedError *client.MigratedError
+    if errors.As(err, &migratedError) {
+        t.Fatal("429 is not a migration")
+    }
+
+    err = &client.ApiError{
+        ErrorCode:   400,
+        Description: "Bad Request: group chat was upgraded to a supergroup chat",
+        Parameters:  &client.ResponseParameters{MigrateToChatId: &migrateToChatId},
+    }
+
+    if !errors.As(err, &migratedError) || migratedError.MigrateToChatId != migrateToChatId {
+        t.Fatalf("MigratedError to %d expected: %+v", migrateToChatId, migratedError)
+    }
+
+    tooManyRequestsError = nil
+    if errors.As(err, &tooManyRequestsError) {
+        t.Fatal("the migration is not a flood wait")
+    }
+}
@@ -1,9 +1,51 @@
 package client
 
 import (
+    "context"
     "fmt"
+    "io"
+    "net/http"
+    "os"
+    "path/filepath"
 )
 
+// GetDownloadLink returns the link to File.FilePath on api.telegram.org.
+//
+// Deprecated: it ignores WithBaseUrl and WithFileBaseUrl, use (*Client).GetDownloadLink.
 func GetDownloadLink(token string, filePath string) string {
     return fmt.Sprintf("%s/file/bot%s/%s", baseUrl, token, filePath)
 }
+
+// GetDownloadLink returns the link to File.FilePath on the configured server.
+// In local mode an absolute path is returned as is.
+func (client *Client) GetDownloadLink(filePath string) string {
+    if client.localMode && filepath.IsAbs(filePath) {
+        return filePath
+    }
+
+    return fmt.Sprintf("%s/file/bot%s/%s", client.fileBaseUrl, client.token, filePath)
+}
+
+// DownloadFile opens File.FilePath for reading. In local mode an absolute path is read from disk.
+func (client *Client) DownloadFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+    if client.localMode && filepath.IsAbs(filePath) {
+        return os.Open(filePath)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, "GET", client.GetDownloadLink(filePath), nil)
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := client.httpClient.Do(req)
+    if err != nil {
+        return nil, err
+    }
+
+    if resp.StatusCode != http.StatusOK {
+        resp.Body.Close()
+        return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+    }
+
+    return resp.Body, nil
+}
@@ -1,12 +1,20 @@
 package client
 
-import "encoding/json"
+import (
+    "context"
+    "encoding/json"
+)
 
 // Use this method to receive incoming updates using long polling (wiki). An Array of Update objects is returned.
 func (client *Client) GetUpdates(req *GetUpdatesRequest) ([]*Update, error) {
+    return client.GetUpdatesCtx(context.Background(), req)
+}
+
+// GetUpdatesCtx is the same as GetUpdates, but the request is bound to ctx.
+func (client *Client) GetUpdatesCtx(ctx context.Context, req *GetUpdatesRequest) ([]*Update, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getUpdates", params)
+    apiResp, err := client.RequestCtx(ctx, "getUpdates", params)
     if err != nil {
         return nil, err
     }
@@ -25,11 +33,40 @@ func (client *Client) GetUpdates(req *GetUpdatesRequest) ([]*Update, error) {
     return resp, nil
 }
 
+// GetUpdatesRawCtx is the same as GetUpdatesCtx, but updates are returned as they are received, e.g. to record them
+// with fields the Update struct doesn't have.
+func (client *Client) GetUpdatesRawCtx(ctx context.Context, req *GetUpdatesRequest) ([]json.RawMessage, error) {
+    params := requestToMap(req)
+
+    apiResp, err := client.RequestCtx(ctx, "getUpdates", params)
+    if err != nil {
+        return nil, err
+    }
+
+    if !apiResp.Ok {
+        return nil, newError(apiResp)
+    }
+
+    var resp []json.RawMessage
+
+    err = json.Unmarshal(apiResp.Result, &resp)
+    if err != nil {
+        return nil, err
+    }
+
+    return resp, nil
+}
+
 // Use this method to specify a url and receive incoming updates via an outgoing webhook. Whenever there is an update for the bot, we will send an HTTPS POST request to the specified url, containing a JSON-serialized Update. In case of an unsuccessful request, we will give up after a reasonable amount of attempts. Returns True on success.
 func (client *Client) SetWebhook(req *SetWebhookRequest) (bool, error) {
+    return client.SetWebhookCtx(context.Background(), req)
+}
+
+// SetWebhookCtx is the same as SetWebhook, but the request is bound to ctx.
+func (client *Client) SetWebhookCtx(ctx context.Context, req *SetWebhookRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setWebhook", params)
+    apiResp, err := client.RequestCtx(ctx, "setWebhook", params)
     if err != nil {
         return false, err
     }
@@ -50,7 +87,12 @@ func (client *Client) SetWebhook(req *SetWebhookRequest) (bool, error) {
 
 // Use this method to remove webhook integration if you decide to switch back to getUpdates. Returns True on success. Requires no parameters.
 func (client *Client) DeleteWebhook() (bool, error) {
-    apiResp, err := client.Request("deleteWebhook", nil)
+    return client.DeleteWebhookCtx(context.Background())
+}
+
+// DeleteWebhookCtx is the same as DeleteWebhook, but the request is bound to ctx.
+func (client *Client) DeleteWebhookCtx(ctx context.Context) (bool, error) {
+    apiResp, err := client.RequestCtx(ctx, "deleteWebhook", nil)
     if err != nil {
         return false, err
     }
@@ -71,7 +113,12 @@ func (client *Client) DeleteWebhook() (bool, error) {
 
 // Use this method to get current webhook status. Requires no parameters. On success, returns a WebhookInfo object. If the bot is using getUpdates, will return an object with the url field empty.
 func (client *Client) GetWebhookInfo() (*WebhookInfo, error) {
-    apiResp, err := client.Request("getWebhookInfo", nil)
+    return client.GetWebhookInfoCtx(context.Background())
+}
+
+// GetWebhookInfoCtx is the same as GetWebhookInfo, but the request is bound to ctx.
+func (client *Client) GetWebhookInfoCtx(ctx context.Context) (*WebhookInfo, error) {
+    apiResp, err := client.RequestCtx(ctx, "getWebhookInfo", nil)
     if err != nil {
         return nil, err
     }
@@ -92,7 +139,12 @@ func (client *Client) GetWebhookInfo() (*WebhookInfo, error) {
 
 // A simple method for testing your bot's auth token. Requires no parameters. Returns basic information about the bot in form of a User object.
 func (client *Client) GetMe() (*User, error) {
-    apiResp, err := client.Request("getMe", nil)
+    return client.GetMeCtx(context.Background())
+}
+
+// GetMeCtx is the same as GetMe, but the request is bound to ctx.
+func (client *Client) GetMeCtx(ctx context.Context) (*User, error) {
+    apiResp, err := client.RequestCtx(ctx, "getMe", nil)
     if err != nil {
         return nil, err
     }
@@ -113,9 +165,14 @@ func (client *Client) GetMe() (*User, error) {
 
 // Use this method to send text messages. On success, the sent Message is returned.
 func (client *Client) SendMessage(req *SendMessageRequest) (*Message, error) {
+    return client.SendMessageCtx(context.Background(), req)
+}
+
+// SendMessageCtx is the same as SendMessage, but the request is bound to ctx.
+func (client *Client) SendMessageCtx(ctx context.Context, req *SendMessageRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendMessage", params)
+    apiResp, err := client.RequestCtx(ctx, "sendMessage", params)
     if err != nil {
         return nil, err
     }
@@ -136,9 +193,14 @@ func (client *Client) SendMessage(req *SendMessageRequest) (*Message, error) {
 
 // Use this method to forward messages of any kind. On success, the sent Message is returned.
 func (client *Client) ForwardMessage(req *ForwardMessageRequest) (*Message, error) {
+    return client.ForwardMessageCtx(context.Background(), req)
+}
+
+// ForwardMessageCtx is the same as ForwardMessage, but the request is bound to ctx.
+func (client *Client) ForwardMessageCtx(ctx context.Context, req *ForwardMessageRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("forwardMessage", params)
+    apiResp, err := client.RequestCtx(ctx, "forwardMessage", params)
     if err != nil {
         return nil, err
     }
@@ -159,9 +221,14 @@ func (client *Client) ForwardMessage(req *ForwardMessageRequest) (*Message, erro
 
 // Use this method to send photos. On success, the sent Message is returned.
 func (client *Client) SendPhoto(req *SendPhotoRequest) (*Message, error) {
+    return client.SendPhotoCtx(context.Background(), req)
+}
+
+// SendPhotoCtx is the same as SendPhoto, but the request is bound to ctx.
+func (client *Client) SendPhotoCtx(ctx context.Context, req *SendPhotoRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendPhoto", params)
+    apiResp, err := client.RequestCtx(ctx, "sendPhoto", params)
     if err != nil {
         return nil, err
     }
@@ -182,9 +249,14 @@ func (client *Client) SendPhoto(req *SendPhotoRequest) (*Message, error) {
 
 // Use this method to send audio files, if you want Telegram clients to display them in the music player. Your audio must be in the .mp3 format. On success, the sent Message is returned. Bots can currently send audio files of up to 50 MB in size, this limit may be changed in the future.
 func (client *Client) SendAudio(req *SendAudioRequest) (*Message, error) {
+    return client.SendAudioCtx(context.Background(), req)
+}
+
+// SendAudioCtx is the same as SendAudio, but the request is bound to ctx.
+func (client *Client) SendAudioCtx(ctx context.Context, req *SendAudioRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendAudio", params)
+    apiResp, err := client.RequestCtx(ctx, "sendAudio", params)
     if err != nil {
         return nil, err
     }
@@ -205,9 +277,14 @@ func (client *Client) SendAudio(req *SendAudioRequest) (*Message, error) {
 
 // Use this method to send general files. On success, the sent Message is returned. Bots can currently send files of any type of up to 50 MB in size, this limit may be changed in the future.
 func (client *Client) SendDocument(req *SendDocumentRequest) (*Message, error) {
+    return client.SendDocumentCtx(context.Background(), req)
+}
+
+// SendDocumentCtx is the same as SendDocument, but the request is bound to ctx.
+func (client *Client) SendDocumentCtx(ctx context.Context, req *SendDocumentRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendDocument", params)
+    apiResp, err := client.RequestCtx(ctx, "sendDocument", params)
     if err != nil {
         return nil, err
     }
@@ -228,9 +305,14 @@ func (client *Client) SendDocument(req *SendDocumentRequest) (*Message, error) {
 
 // Use this method to send video files, Telegram clients support mp4 videos (other formats may be sent as Document). On success, the sent Message is returned. Bots can currently send video files of up to 50 MB in size, this limit may be changed in the future.
 func (client *Client) SendVideo(req *SendVideoRequest) (*Message, error) {
+    return client.SendVideoCtx(context.Background(), req)
+}
+
+// SendVideoCtx is the same as SendVideo, but the request is bound to ctx.
+func (client *Client) SendVideoCtx(ctx context.Context, req *SendVideoRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendVideo", params)
+    apiResp, err := client.RequestCtx(ctx, "sendVideo", params)
     if err != nil {
         return nil, err
     }
@@ -251,9 +333,14 @@ func (client *Client) SendVideo(req *SendVideoRequest) (*Message, error) {
 
 // Use this method to send animation files (GIF or H.264/MPEG-4 AVC video without sound). On success, the sent Message is returned. Bots can currently send animation files of up to 50 MB in size, this limit may be changed in the future.
 func (client *Client) SendAnimation(req *SendAnimationRequest) (*Message, error) {
+    return client.SendAnimationCtx(context.Background(), req)
+}
+
+// SendAnimationCtx is the same as SendAnimation, but the request is bound to ctx.
+func (client *Client) SendAnimationCtx(ctx context.Context, req *SendAnimationRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendAnimation", params)
+    apiResp, err := client.RequestCtx(ctx, "sendAnimation", params)
     if err != nil {
         return nil, err
     }
@@ -274,9 +361,14 @@ func (client *Client) SendAnimation(req *SendAnimationRequest) (*Message, error)
 
 // Use this method to send audio files, if you want Telegram clients to display the file as a playable voice message. For this to work, your audio must be in an .ogg file encoded with OPUS (other formats may be sent as Audio or Document). On success, the sent Message is returned. Bots can currently send voice messages of up to 50 MB in size, this limit may be changed in the future.
 func (client *Client) SendVoice(req *SendVoiceRequest) (*Message, error) {
+    return client.SendVoiceCtx(context.Background(), req)
+}
+
+// SendVoiceCtx is the same as SendVoice, but the request is bound to ctx.
+func (client *Client) SendVoiceCtx(ctx context.Context, req *SendVoiceRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendVoice", params)
+    apiResp, err := client.RequestCtx(ctx, "sendVoice", params)
     if err != nil {
         return nil, err
     }
@@ -297,9 +389,14 @@ func (client *Client) SendVoice(req *SendVoiceRequest) (*Message, error) {
 
 // As of v.4.0, Telegram clients support rounded square mp4 videos of up to 1 minute long. Use this method to send video messages. On success, the sent Message is returned.
 func (client *Client) SendVideoNote(req *SendVideoNoteRequest) (*Message, error) {
+    return client.SendVideoNoteCtx(context.Background(), req)
+}
+
+// SendVideoNoteCtx is the same as SendVideoNote, but the request is bound to ctx.
+func (client *Client) SendVideoNoteCtx(ctx context.Context, req *SendVideoNoteRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendVideoNote", params)
+    apiResp, err := client.RequestCtx(ctx, "sendVideoNote", params)
     if err != nil {
         return nil, err
     }
@@ -320,9 +417,14 @@ func (client *Client) SendVideoNote(req *SendVideoNoteRequest) (*Message, error)
 
 // Use this method to send a group of photos or videos as an album. On success, an array of the sent Messages is returned.
 func (client *Client) SendMediaGroup(req *SendMediaGroupRequest) ([]*Message, error) {
+    return client.SendMediaGroupCtx(context.Background(), req)
+}
+
+// SendMediaGroupCtx is the same as SendMediaGroup, but the request is bound to ctx.
+func (client *Client) SendMediaGroupCtx(ctx context.Context, req *SendMediaGroupRequest) ([]*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendMediaGroup", params)
+    apiResp, err := client.RequestCtx(ctx, "sendMediaGroup", params)
     if err != nil {
         return nil, err
     }
@@ -343,9 +445,14 @@ func (client *Client) SendMediaGroup(req *SendMediaGroupRequest) ([]*Message, er
 
 // Use this method to send point on the map. On success, the sent Message is returned.
 func (client *Client) SendLocation(req *SendLocationRequest) (*Message, error) {
+    return client.SendLocationCtx(context.Background(), req)
+}
+
+// SendLocationCtx is the same as SendLocation, but the request is bound to ctx.
+func (client *Client) SendLocationCtx(ctx context.Context, req *SendLocationRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendLocation", params)
+    apiResp, err := client.RequestCtx(ctx, "sendLocation", params)
     if err != nil {
         return nil, err
     }
@@ -366,9 +473,14 @@ func (client *Client) SendLocation(req *SendLocationRequest) (*Message, error) {
 
 // Use this method to edit live location messages sent by the bot or via the bot (for inline bots). A location can be edited until its live_period expires or editing is explicitly disabled by a call to stopMessageLiveLocation. On success, if the edited message was sent by the bot, the edited Message is returned, otherwise True is returned.
 func (client *Client) EditMessageLiveLocation(req *EditMessageLiveLocationRequest) (*Message, error) {
+    return client.EditMessageLiveLocationCtx(context.Background(), req)
+}
+
+// EditMessageLiveLocationCtx is the same as EditMessageLiveLocation, but the request is bound to ctx.
+func (client *Client) EditMessageLiveLocationCtx(ctx context.Context, req *EditMessageLiveLocationRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("editMessageLiveLocation", params)
+    apiResp, err := client.RequestCtx(ctx, "editMessageLiveLocation", params)
     if err != nil {
         return nil, err
     }
@@ -377,6 +489,11 @@ func (client *Client) EditMessageLiveLocation(req *EditMessageLiveLocationReques
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -389,9 +506,14 @@ func (client *Client) EditMessageLiveLocation(req *EditMessageLiveLocationReques
 
 // Use this method to stop updating a live location message sent by the bot or via the bot (for inline bots) before live_period expires. On success, if the message was sent by the bot, the sent Message is returned, otherwise True is returned.
 func (client *Client) StopMessageLiveLocation(req *StopMessageLiveLocationRequest) (*Message, error) {
+    return client.StopMessageLiveLocationCtx(context.Background(), req)
+}
+
+// StopMessageLiveLocationCtx is the same as StopMessageLiveLocation, but the request is bound to ctx.
+func (client *Client) StopMessageLiveLocationCtx(ctx context.Context, req *StopMessageLiveLocationRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("stopMessageLiveLocation", params)
+    apiResp, err := client.RequestCtx(ctx, "stopMessageLiveLocation", params)
     if err != nil {
         return nil, err
     }
@@ -400,6 +522,11 @@ func (client *Client) StopMessageLiveLocation(req *StopMessageLiveLocationReques
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -412,9 +539,14 @@ func (client *Client) StopMessageLiveLocation(req *StopMessageLiveLocationReques
 
 // Use this method to send information about a venue. On success, the sent Message is returned.
 func (client *Client) SendVenue(req *SendVenueRequest) (*Message, error) {
+    return client.SendVenueCtx(context.Background(), req)
+}
+
+// SendVenueCtx is the same as SendVenue, but the request is bound to ctx.
+func (client *Client) SendVenueCtx(ctx context.Context, req *SendVenueRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendVenue", params)
+    apiResp, err := client.RequestCtx(ctx, "sendVenue", params)
     if err != nil {
         return nil, err
     }
@@ -435,9 +567,14 @@ func (client *Client) SendVenue(req *SendVenueRequest) (*Message, error) {
 
 // Use this method to send phone contacts. On success, the sent Message is returned.
 func (client *Client) SendContact(req *SendContactRequest) (*Message, error) {
+    return client.SendContactCtx(context.Background(), req)
+}
+
+// SendContactCtx is the same as SendContact, but the request is bound to ctx.
+func (client *Client) SendContactCtx(ctx context.Context, req *SendContactRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendContact", params)
+    apiResp, err := client.RequestCtx(ctx, "sendContact", params)
     if err != nil {
         return nil, err
     }
@@ -458,9 +595,14 @@ func (client *Client) SendContact(req *SendContactRequest) (*Message, error) {
 
 // Use this method when you need to tell the user that something is happening on the bot's side. The status is set for 5 seconds or less (when a message arrives from your bot, Telegram clients clear its typing status). Returns True on success.
 func (client *Client) SendChatAction(req *SendChatActionRequest) (bool, error) {
+    return client.SendChatActionCtx(context.Background(), req)
+}
+
+// SendChatActionCtx is the same as SendChatAction, but the request is bound to ctx.
+func (client *Client) SendChatActionCtx(ctx context.Context, req *SendChatActionRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendChatAction", params)
+    apiResp, err := client.RequestCtx(ctx, "sendChatAction", params)
     if err != nil {
         return false, err
     }
@@ -481,9 +623,14 @@ func (client *Client) SendChatAction(req *SendChatActionRequest) (bool, error) {
 
 // Use this method to get a list of profile pictures for a user. Returns a UserProfilePhotos object.
 func (client *Client) GetUserProfilePhotos(req *GetUserProfilePhotosRequest) (*UserProfilePhotos, error) {
+    return client.GetUserProfilePhotosCtx(context.Background(), req)
+}
+
+// GetUserProfilePhotosCtx is the same as GetUserProfilePhotos, but the request is bound to ctx.
+func (client *Client) GetUserProfilePhotosCtx(ctx context.Context, req *GetUserProfilePhotosRequest) (*UserProfilePhotos, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getUserProfilePhotos", params)
+    apiResp, err := client.RequestCtx(ctx, "getUserProfilePhotos", params)
     if err != nil {
         return nil, err
     }
@@ -504,9 +651,14 @@ func (client *Client) GetUserProfilePhotos(req *GetUserProfilePhotosRequest) (*U
 
 // Use this method to get basic info about a file and prepare it for downloading. For the moment, bots can download files of up to 20MB in size. On success, a File object is returned. The file can then be downloaded via the link https://api.telegram.org/file/bot<token>/<file_path>, where <file_path> is taken from the response. It is guaranteed that the link will be valid for at least 1 hour. When the link expires, a new one can be requested by calling getFile again.
 func (client *Client) GetFile(req *GetFileRequest) (*File, error) {
+    return client.GetFileCtx(context.Background(), req)
+}
+
+// GetFileCtx is the same as GetFile, but the request is bound to ctx.
+func (client *Client) GetFileCtx(ctx context.Context, req *GetFileRequest) (*File, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getFile", params)
+    apiResp, err := client.RequestCtx(ctx, "getFile", params)
     if err != nil {
         return nil, err
     }
@@ -527,9 +679,14 @@ func (client *Client) GetFile(req *GetFileRequest) (*File, error) {
 
 // Use this method to kick a user from a group, a supergroup or a channel. In the case of supergroups and channels, the user will not be able to return to the group on their own using invite links, etc., unless unbanned first. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Returns True on success.
 func (client *Client) KickChatMember(req *KickChatMemberRequest) (bool, error) {
+    return client.KickChatMemberCtx(context.Background(), req)
+}
+
+// KickChatMemberCtx is the same as KickChatMember, but the request is bound to ctx.
+func (client *Client) KickChatMemberCtx(ctx context.Context, req *KickChatMemberRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("kickChatMember", params)
+    apiResp, err := client.RequestCtx(ctx, "kickChatMember", params)
     if err != nil {
         return false, err
     }
@@ -550,9 +707,14 @@ func (client *Client) KickChatMember(req *KickChatMemberRequest) (bool, error) {
 
 // Use this method to unban a previously kicked user in a supergroup or channel. The user will not return to the group or channel automatically, but will be able to join via link, etc. The bot must be an administrator for this to work. Returns True on success.
 func (client *Client) UnbanChatMember(req *UnbanChatMemberRequest) (bool, error) {
+    return client.UnbanChatMemberCtx(context.Background(), req)
+}
+
+// UnbanChatMemberCtx is the same as UnbanChatMember, but the request is bound to ctx.
+func (client *Client) UnbanChatMemberCtx(ctx context.Context, req *UnbanChatMemberRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("unbanChatMember", params)
+    apiResp, err := client.RequestCtx(ctx, "unbanChatMember", params)
     if err != nil {
         return false, err
     }
@@ -573,9 +735,14 @@ func (client *Client) UnbanChatMember(req *UnbanChatMemberRequest) (bool, error)
 
 // Use this method to restrict a user in a supergroup. The bot must be an administrator in the supergroup for this to work and must have the appropriate admin rights. Pass True for all boolean parameters to lift restrictions from a user. Returns True on success.
 func (client *Client) RestrictChatMember(req *RestrictChatMemberRequest) (bool, error) {
+    return client.RestrictChatMemberCtx(context.Background(), req)
+}
+
+// RestrictChatMemberCtx is the same as RestrictChatMember, but the request is bound to ctx.
+func (client *Client) RestrictChatMemberCtx(ctx context.Context, req *RestrictChatMemberRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("restrictChatMember", params)
+    apiResp, err := client.RequestCtx(ctx, "restrictChatMember", params)
     if err != nil {
         return false, err
     }
@@ -596,9 +763,14 @@ func (client *Client) RestrictChatMember(req *RestrictChatMemberRequest) (bool,
 
 // Use this method to promote or demote a user in a supergroup or a channel. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Pass False for all boolean parameters to demote a user. Returns True on success.
 func (client *Client) PromoteChatMember(req *PromoteChatMemberRequest) (bool, error) {
+    return client.PromoteChatMemberCtx(context.Background(), req)
+}
+
+// PromoteChatMemberCtx is the same as PromoteChatMember, but the request is bound to ctx.
+func (client *Client) PromoteChatMemberCtx(ctx context.Context, req *PromoteChatMemberRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("promoteChatMember", params)
+    apiResp, err := client.RequestCtx(ctx, "promoteChatMember", params)
     if err != nil {
         return false, err
     }
@@ -619,9 +791,14 @@ func (client *Client) PromoteChatMember(req *PromoteChatMemberRequest) (bool, er
 
 // Use this method to generate a new invite link for a chat; any previously generated link is revoked. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Returns the new invite link as String on success.
 func (client *Client) ExportChatInviteLink(req *ExportChatInviteLinkRequest) (string, error) {
+    return client.ExportChatInviteLinkCtx(context.Background(), req)
+}
+
+// ExportChatInviteLinkCtx is the same as ExportChatInviteLink, but the request is bound to ctx.
+func (client *Client) ExportChatInviteLinkCtx(ctx context.Context, req *ExportChatInviteLinkRequest) (string, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("exportChatInviteLink", params)
+    apiResp, err := client.RequestCtx(ctx, "exportChatInviteLink", params)
     if err != nil {
         return "", err
     }
@@ -642,9 +819,14 @@ func (client *Client) ExportChatInviteLink(req *ExportChatInviteLinkRequest) (st
 
 // Use this method to set a new profile photo for the chat. Photos can't be changed for private chats. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Returns True on success.
 func (client *Client) SetChatPhoto(req *SetChatPhotoRequest) (bool, error) {
+    return client.SetChatPhotoCtx(context.Background(), req)
+}
+
+// SetChatPhotoCtx is the same as SetChatPhoto, but the request is bound to ctx.
+func (client *Client) SetChatPhotoCtx(ctx context.Context, req *SetChatPhotoRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setChatPhoto", params)
+    apiResp, err := client.RequestCtx(ctx, "setChatPhoto", params)
     if err != nil {
         return false, err
     }
@@ -665,9 +847,14 @@ func (client *Client) SetChatPhoto(req *SetChatPhotoRequest) (bool, error) {
 
 // Use this method to delete a chat photo. Photos can't be changed for private chats. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Returns True on success.
 func (client *Client) DeleteChatPhoto(req *DeleteChatPhotoRequest) (bool, error) {
+    return client.DeleteChatPhotoCtx(context.Background(), req)
+}
+
+// DeleteChatPhotoCtx is the same as DeleteChatPhoto, but the request is bound to ctx.
+func (client *Client) DeleteChatPhotoCtx(ctx context.Context, req *DeleteChatPhotoRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("deleteChatPhoto", params)
+    apiResp, err := client.RequestCtx(ctx, "deleteChatPhoto", params)
     if err != nil {
         return false, err
     }
@@ -688,9 +875,14 @@ func (client *Client) DeleteChatPhoto(req *DeleteChatPhotoRequest) (bool, error)
 
 // Use this method to change the title of a chat. Titles can't be changed for private chats. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Returns True on success.
 func (client *Client) SetChatTitle(req *SetChatTitleRequest) (bool, error) {
+    return client.SetChatTitleCtx(context.Background(), req)
+}
+
+// SetChatTitleCtx is the same as SetChatTitle, but the request is bound to ctx.
+func (client *Client) SetChatTitleCtx(ctx context.Context, req *SetChatTitleRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setChatTitle", params)
+    apiResp, err := client.RequestCtx(ctx, "setChatTitle", params)
     if err != nil {
         return false, err
     }
@@ -711,9 +903,14 @@ func (client *Client) SetChatTitle(req *SetChatTitleRequest) (bool, error) {
 
 // Use this method to change the description of a supergroup or a channel. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Returns True on success.
 func (client *Client) SetChatDescription(req *SetChatDescriptionRequest) (bool, error) {
+    return client.SetChatDescriptionCtx(context.Background(), req)
+}
+
+// SetChatDescriptionCtx is the same as SetChatDescription, but the request is bound to ctx.
+func (client *Client) SetChatDescriptionCtx(ctx context.Context, req *SetChatDescriptionRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setChatDescription", params)
+    apiResp, err := client.RequestCtx(ctx, "setChatDescription", params)
     if err != nil {
         return false, err
     }
@@ -734,9 +931,14 @@ func (client *Client) SetChatDescription(req *SetChatDescriptionRequest) (bool,
 
 // Use this method to pin a message in a supergroup or a channel. The bot must be an administrator in the chat for this to work and must have the ‘can_pin_messages’ admin right in the supergroup or ‘can_edit_messages’ admin right in the channel. Returns True on success.
 func (client *Client) PinChatMessage(req *PinChatMessageRequest) (bool, error) {
+    return client.PinChatMessageCtx(context.Background(), req)
+}
+
+// PinChatMessageCtx is the same as PinChatMessage, but the request is bound to ctx.
+func (client *Client) PinChatMessageCtx(ctx context.Context, req *PinChatMessageRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("pinChatMessage", params)
+    apiResp, err := client.RequestCtx(ctx, "pinChatMessage", params)
     if err != nil {
         return false, err
     }
@@ -757,9 +959,14 @@ func (client *Client) PinChatMessage(req *PinChatMessageRequest) (bool, error) {
 
 // Use this method to unpin a message in a supergroup or a channel. The bot must be an administrator in the chat for this to work and must have the ‘can_pin_messages’ admin right in the supergroup or ‘can_edit_messages’ admin right in the channel. Returns True on success.
 func (client *Client) UnpinChatMessage(req *UnpinChatMessageRequest) (bool, error) {
+    return client.UnpinChatMessageCtx(context.Background(), req)
+}
+
+// UnpinChatMessageCtx is the same as UnpinChatMessage, but the request is bound to ctx.
+func (client *Client) UnpinChatMessageCtx(ctx context.Context, req *UnpinChatMessageRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("unpinChatMessage", params)
+    apiResp, err := client.RequestCtx(ctx, "unpinChatMessage", params)
     if err != nil {
         return false, err
     }
@@ -780,9 +987,14 @@ func (client *Client) UnpinChatMessage(req *UnpinChatMessageRequest) (bool, erro
 
 // Use this method for your bot to leave a group, supergroup or channel. Returns True on success.
 func (client *Client) LeaveChat(req *LeaveChatRequest) (bool, error) {
+    return client.LeaveChatCtx(context.Background(), req)
+}
+
+// LeaveChatCtx is the same as LeaveChat, but the request is bound to ctx.
+func (client *Client) LeaveChatCtx(ctx context.Context, req *LeaveChatRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("leaveChat", params)
+    apiResp, err := client.RequestCtx(ctx, "leaveChat", params)
     if err != nil {
         return false, err
     }
@@ -803,9 +1015,14 @@ func (client *Client) LeaveChat(req *LeaveChatRequest) (bool, error) {
 
 // Use this method to get up to date information about the chat (current name of the user for one-on-one conversations, current username of a user, group or channel, etc.). Returns a Chat object on success.
 func (client *Client) GetChat(req *GetChatRequest) (*Chat, error) {
+    return client.GetChatCtx(context.Background(), req)
+}
+
+// GetChatCtx is the same as GetChat, but the request is bound to ctx.
+func (client *Client) GetChatCtx(ctx context.Context, req *GetChatRequest) (*Chat, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getChat", params)
+    apiResp, err := client.RequestCtx(ctx, "getChat", params)
     if err != nil {
         return nil, err
     }
@@ -826,9 +1043,14 @@ func (client *Client) GetChat(req *GetChatRequest) (*Chat, error) {
 
 // Use this method to get a list of administrators in a chat. On success, returns an Array of ChatMember objects that contains information about all chat administrators except other bots. If the chat is a group or a supergroup and no administrators were appointed, only the creator will be returned.
 func (client *Client) GetChatAdministrators(req *GetChatAdministratorsRequest) ([]*ChatMember, error) {
+    return client.GetChatAdministratorsCtx(context.Background(), req)
+}
+
+// GetChatAdministratorsCtx is the same as GetChatAdministrators, but the request is bound to ctx.
+func (client *Client) GetChatAdministratorsCtx(ctx context.Context, req *GetChatAdministratorsRequest) ([]*ChatMember, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getChatAdministrators", params)
+    apiResp, err := client.RequestCtx(ctx, "getChatAdministrators", params)
     if err != nil {
         return nil, err
     }
@@ -849,9 +1071,14 @@ func (client *Client) GetChatAdministrators(req *GetChatAdministratorsRequest) (
 
 // Use this method to get the number of members in a chat. Returns Int on success.
 func (client *Client) GetChatMembersCount(req *GetChatMembersCountRequest) (int64, error) {
+    return client.GetChatMembersCountCtx(context.Background(), req)
+}
+
+// GetChatMembersCountCtx is the same as GetChatMembersCount, but the request is bound to ctx.
+func (client *Client) GetChatMembersCountCtx(ctx context.Context, req *GetChatMembersCountRequest) (int64, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getChatMembersCount", params)
+    apiResp, err := client.RequestCtx(ctx, "getChatMembersCount", params)
     if err != nil {
         return 0, err
     }
@@ -872,9 +1099,14 @@ func (client *Client) GetChatMembersCount(req *GetChatMembersCountRequest) (int6
 
 // Use this method to get information about a member of a chat. Returns a ChatMember object on success.
 func (client *Client) GetChatMember(req *GetChatMemberRequest) (*ChatMember, error) {
+    return client.GetChatMemberCtx(context.Background(), req)
+}
+
+// GetChatMemberCtx is the same as GetChatMember, but the request is bound to ctx.
+func (client *Client) GetChatMemberCtx(ctx context.Context, req *GetChatMemberRequest) (*ChatMember, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getChatMember", params)
+    apiResp, err := client.RequestCtx(ctx, "getChatMember", params)
     if err != nil {
         return nil, err
     }
@@ -895,9 +1127,14 @@ func (client *Client) GetChatMember(req *GetChatMemberRequest) (*ChatMember, err
 
 // Use this method to set a new group sticker set for a supergroup. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Use the field can_set_sticker_set optionally returned in getChat requests to check if the bot can use this method. Returns True on success.
 func (client *Client) SetChatStickerSet(req *SetChatStickerSetRequest) (bool, error) {
+    return client.SetChatStickerSetCtx(context.Background(), req)
+}
+
+// SetChatStickerSetCtx is the same as SetChatStickerSet, but the request is bound to ctx.
+func (client *Client) SetChatStickerSetCtx(ctx context.Context, req *SetChatStickerSetRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setChatStickerSet", params)
+    apiResp, err := client.RequestCtx(ctx, "setChatStickerSet", params)
     if err != nil {
         return false, err
     }
@@ -918,9 +1155,14 @@ func (client *Client) SetChatStickerSet(req *SetChatStickerSetRequest) (bool, er
 
 // Use this method to delete a group sticker set from a supergroup. The bot must be an administrator in the chat for this to work and must have the appropriate admin rights. Use the field can_set_sticker_set optionally returned in getChat requests to check if the bot can use this method. Returns True on success.
 func (client *Client) DeleteChatStickerSet(req *DeleteChatStickerSetRequest) (bool, error) {
+    return client.DeleteChatStickerSetCtx(context.Background(), req)
+}
+
+// DeleteChatStickerSetCtx is the same as DeleteChatStickerSet, but the request is bound to ctx.
+func (client *Client) DeleteChatStickerSetCtx(ctx context.Context, req *DeleteChatStickerSetRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("deleteChatStickerSet", params)
+    apiResp, err := client.RequestCtx(ctx, "deleteChatStickerSet", params)
     if err != nil {
         return false, err
     }
@@ -941,9 +1183,14 @@ func (client *Client) DeleteChatStickerSet(req *DeleteChatStickerSetRequest) (bo
 
 // Use this method to send answers to callback queries sent from inline keyboards. The answer will be displayed to the user as a notification at the top of the chat screen or as an alert. On success, True is returned.
 func (client *Client) AnswerCallbackQuery(req *AnswerCallbackQueryRequest) (bool, error) {
+    return client.AnswerCallbackQueryCtx(context.Background(), req)
+}
+
+// AnswerCallbackQueryCtx is the same as AnswerCallbackQuery, but the request is bound to ctx.
+func (client *Client) AnswerCallbackQueryCtx(ctx context.Context, req *AnswerCallbackQueryRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("answerCallbackQuery", params)
+    apiResp, err := client.RequestCtx(ctx, "answerCallbackQuery", params)
     if err != nil {
         return false, err
     }
@@ -964,9 +1211,14 @@ func (client *Client) AnswerCallbackQuery(req *AnswerCallbackQueryRequest) (bool
 
 // Use this method to edit text and game messages sent by the bot or via the bot (for inline bots). On success, if edited message is sent by the bot, the edited Message is returned, otherwise True is returned.
 func (client *Client) EditMessageText(req *EditMessageTextRequest) (*Message, error) {
+    return client.EditMessageTextCtx(context.Background(), req)
+}
+
+// EditMessageTextCtx is the same as EditMessageText, but the request is bound to ctx.
+func (client *Client) EditMessageTextCtx(ctx context.Context, req *EditMessageTextRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("editMessageText", params)
+    apiResp, err := client.RequestCtx(ctx, "editMessageText", params)
     if err != nil {
         return nil, err
     }
@@ -975,6 +1227,11 @@ func (client *Client) EditMessageText(req *EditMessageTextRequest) (*Message, er
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -987,9 +1244,14 @@ func (client *Client) EditMessageText(req *EditMessageTextRequest) (*Message, er
 
 // Use this method to edit captions of messages sent by the bot or via the bot (for inline bots). On success, if edited message is sent by the bot, the edited Message is returned, otherwise True is returned.
 func (client *Client) EditMessageCaption(req *EditMessageCaptionRequest) (*Message, error) {
+    return client.EditMessageCaptionCtx(context.Background(), req)
+}
+
+// EditMessageCaptionCtx is the same as EditMessageCaption, but the request is bound to ctx.
+func (client *Client) EditMessageCaptionCtx(ctx context.Context, req *EditMessageCaptionRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("editMessageCaption", params)
+    apiResp, err := client.RequestCtx(ctx, "editMessageCaption", params)
     if err != nil {
         return nil, err
     }
@@ -998,6 +1260,11 @@ func (client *Client) EditMessageCaption(req *EditMessageCaptionRequest) (*Messa
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -1010,9 +1277,14 @@ func (client *Client) EditMessageCaption(req *EditMessageCaptionRequest) (*Messa
 
 // Use this method to edit audio, document, photo, or video messages. If a message is a part of a message album, then it can be edited only to a photo or a video. Otherwise, message type can be changed arbitrarily. When inline message is edited, new file can't be uploaded. Use previously uploaded file via its file_id or specify a URL. On success, if the edited message was sent by the bot, the edited Message is returned, otherwise True is returned.
 func (client *Client) EditMessageMedia(req *EditMessageMediaRequest) (*Message, error) {
+    return client.EditMessageMediaCtx(context.Background(), req)
+}
+
+// EditMessageMediaCtx is the same as EditMessageMedia, but the request is bound to ctx.
+func (client *Client) EditMessageMediaCtx(ctx context.Context, req *EditMessageMediaRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("editMessageMedia", params)
+    apiResp, err := client.RequestCtx(ctx, "editMessageMedia", params)
     if err != nil {
         return nil, err
     }
@@ -1021,6 +1293,11 @@ func (client *Client) EditMessageMedia(req *EditMessageMediaRequest) (*Message,
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -1033,9 +1310,14 @@ func (client *Client) EditMessageMedia(req *EditMessageMediaRequest) (*Message,
 
 // Use this method to edit only the reply markup of messages sent by the bot or via the bot (for inline bots).  On success, if edited message is sent by the bot, the edited Message is returned, otherwise True is returned.
 func (client *Client) EditMessageReplyMarkup(req *EditMessageReplyMarkupRequest) (*Message, error) {
+    return client.EditMessageReplyMarkupCtx(context.Background(), req)
+}
+
+// EditMessageReplyMarkupCtx is the same as EditMessageReplyMarkup, but the request is bound to ctx.
+func (client *Client) EditMessageReplyMarkupCtx(ctx context.Context, req *EditMessageReplyMarkupRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("editMessageReplyMarkup", params)
+    apiResp, err := client.RequestCtx(ctx, "editMessageReplyMarkup", params)
     if err != nil {
         return nil, err
     }
@@ -1044,6 +1326,11 @@ func (client *Client) EditMessageReplyMarkup(req *EditMessageReplyMarkupRequest)
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -1056,9 +1343,14 @@ func (client *Client) EditMessageReplyMarkup(req *EditMessageReplyMarkupRequest)
 
 // Use this method to delete a message, including service messages, with the following limitations:- A message can only be deleted if it was sent less than 48 hours ago.- Bots can delete outgoing messages in groups and supergroups.- Bots granted can_post_messages permissions can delete outgoing messages in channels.- If the bot is an administrator of a group, it can delete any message there.- If the bot has can_delete_messages permission in a supergroup or a channel, it can delete any message there.Returns True on success.
 func (client *Client) DeleteMessage(req *DeleteMessageRequest) (bool, error) {
+    return client.DeleteMessageCtx(context.Background(), req)
+}
+
+// DeleteMessageCtx is the same as DeleteMessage, but the request is bound to ctx.
+func (client *Client) DeleteMessageCtx(ctx context.Context, req *DeleteMessageRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("deleteMessage", params)
+    apiResp, err := client.RequestCtx(ctx, "deleteMessage", params)
     if err != nil {
         return false, err
     }
@@ -1079,9 +1371,14 @@ func (client *Client) DeleteMessage(req *DeleteMessageRequest) (bool, error) {
 
 // Use this method to send .webp stickers. On success, the sent Message is returned.
 func (client *Client) SendSticker(req *SendStickerRequest) (*Message, error) {
+    return client.SendStickerCtx(context.Background(), req)
+}
+
+// SendStickerCtx is the same as SendSticker, but the request is bound to ctx.
+func (client *Client) SendStickerCtx(ctx context.Context, req *SendStickerRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendSticker", params)
+    apiResp, err := client.RequestCtx(ctx, "sendSticker", params)
     if err != nil {
         return nil, err
     }
@@ -1102,9 +1399,14 @@ func (client *Client) SendSticker(req *SendStickerRequest) (*Message, error) {
 
 // Use this method to get a sticker set. On success, a StickerSet object is returned.
 func (client *Client) GetStickerSet(req *GetStickerSetRequest) (*StickerSet, error) {
+    return client.GetStickerSetCtx(context.Background(), req)
+}
+
+// GetStickerSetCtx is the same as GetStickerSet, but the request is bound to ctx.
+func (client *Client) GetStickerSetCtx(ctx context.Context, req *GetStickerSetRequest) (*StickerSet, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getStickerSet", params)
+    apiResp, err := client.RequestCtx(ctx, "getStickerSet", params)
     if err != nil {
         return nil, err
     }
@@ -1125,9 +1427,14 @@ func (client *Client) GetStickerSet(req *GetStickerSetRequest) (*StickerSet, err
 
 // Use this method to upload a .png file with a sticker for later use in createNewStickerSet and addStickerToSet methods (can be used multiple times). Returns the uploaded File on success.
 func (client *Client) UploadStickerFile(req *UploadStickerFileRequest) (*File, error) {
+    return client.UploadStickerFileCtx(context.Background(), req)
+}
+
+// UploadStickerFileCtx is the same as UploadStickerFile, but the request is bound to ctx.
+func (client *Client) UploadStickerFileCtx(ctx context.Context, req *UploadStickerFileRequest) (*File, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("uploadStickerFile", params)
+    apiResp, err := client.RequestCtx(ctx, "uploadStickerFile", params)
     if err != nil {
         return nil, err
     }
@@ -1148,9 +1455,14 @@ func (client *Client) UploadStickerFile(req *UploadStickerFileRequest) (*File, e
 
 // Use this method to create new sticker set owned by a user. The bot will be able to edit the created sticker set. Returns True on success.
 func (client *Client) CreateNewStickerSet(req *CreateNewStickerSetRequest) (bool, error) {
+    return client.CreateNewStickerSetCtx(context.Background(), req)
+}
+
+// CreateNewStickerSetCtx is the same as CreateNewStickerSet, but the request is bound to ctx.
+func (client *Client) CreateNewStickerSetCtx(ctx context.Context, req *CreateNewStickerSetRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("createNewStickerSet", params)
+    apiResp, err := client.RequestCtx(ctx, "createNewStickerSet", params)
     if err != nil {
         return false, err
     }
@@ -1171,9 +1483,14 @@ func (client *Client) CreateNewStickerSet(req *CreateNewStickerSetRequest) (bool
 
 // Use this method to add a new sticker to a set created by the bot. Returns True on success.
 func (client *Client) AddStickerToSet(req *AddStickerToSetRequest) (bool, error) {
+    return client.AddStickerToSetCtx(context.Background(), req)
+}
+
+// AddStickerToSetCtx is the same as AddStickerToSet, but the request is bound to ctx.
+func (client *Client) AddStickerToSetCtx(ctx context.Context, req *AddStickerToSetRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("addStickerToSet", params)
+    apiResp, err := client.RequestCtx(ctx, "addStickerToSet", params)
     if err != nil {
         return false, err
     }
@@ -1194,9 +1511,14 @@ func (client *Client) AddStickerToSet(req *AddStickerToSetRequest) (bool, error)
 
 // Use this method to move a sticker in a set created by the bot to a specific position . Returns True on success.
 func (client *Client) SetStickerPositionInSet(req *SetStickerPositionInSetRequest) (bool, error) {
+    return client.SetStickerPositionInSetCtx(context.Background(), req)
+}
+
+// SetStickerPositionInSetCtx is the same as SetStickerPositionInSet, but the request is bound to ctx.
+func (client *Client) SetStickerPositionInSetCtx(ctx context.Context, req *SetStickerPositionInSetRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setStickerPositionInSet", params)
+    apiResp, err := client.RequestCtx(ctx, "setStickerPositionInSet", params)
     if err != nil {
         return false, err
     }
@@ -1217,9 +1539,14 @@ func (client *Client) SetStickerPositionInSet(req *SetStickerPositionInSetReques
 
 // Use this method to delete a sticker from a set created by the bot. Returns True on success.
 func (client *Client) DeleteStickerFromSet(req *DeleteStickerFromSetRequest) (bool, error) {
+    return client.DeleteStickerFromSetCtx(context.Background(), req)
+}
+
+// DeleteStickerFromSetCtx is the same as DeleteStickerFromSet, but the request is bound to ctx.
+func (client *Client) DeleteStickerFromSetCtx(ctx context.Context, req *DeleteStickerFromSetRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("deleteStickerFromSet", params)
+    apiResp, err := client.RequestCtx(ctx, "deleteStickerFromSet", params)
     if err != nil {
         return false, err
     }
@@ -1240,9 +1567,14 @@ func (client *Client) DeleteStickerFromSet(req *DeleteStickerFromSetRequest) (bo
 
 // Use this method to send answers to an inline query. On success, True is returned.No more than 50 results per query are allowed.
 func (client *Client) AnswerInlineQuery(req *AnswerInlineQueryRequest) (bool, error) {
+    return client.AnswerInlineQueryCtx(context.Background(), req)
+}
+
+// AnswerInlineQueryCtx is the same as AnswerInlineQuery, but the request is bound to ctx.
+func (client *Client) AnswerInlineQueryCtx(ctx context.Context, req *AnswerInlineQueryRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("answerInlineQuery", params)
+    apiResp, err := client.RequestCtx(ctx, "answerInlineQuery", params)
     if err != nil {
         return false, err
     }
@@ -1263,9 +1595,14 @@ func (client *Client) AnswerInlineQuery(req *AnswerInlineQueryRequest) (bool, er
 
 // Use this method to send invoices. On success, the sent Message is returned.
 func (client *Client) SendInvoice(req *SendInvoiceRequest) (*Message, error) {
+    return client.SendInvoiceCtx(context.Background(), req)
+}
+
+// SendInvoiceCtx is the same as SendInvoice, but the request is bound to ctx.
+func (client *Client) SendInvoiceCtx(ctx context.Context, req *SendInvoiceRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendInvoice", params)
+    apiResp, err := client.RequestCtx(ctx, "sendInvoice", params)
     if err != nil {
         return nil, err
     }
@@ -1286,9 +1623,14 @@ func (client *Client) SendInvoice(req *SendInvoiceRequest) (*Message, error) {
 
 // If you sent an invoice requesting a shipping address and the parameter is_flexible was specified, the Bot API will send an Update with a shipping_query field to the bot. Use this method to reply to shipping queries. On success, True is returned.
 func (client *Client) AnswerShippingQuery(req *AnswerShippingQueryRequest) (bool, error) {
+    return client.AnswerShippingQueryCtx(context.Background(), req)
+}
+
+// AnswerShippingQueryCtx is the same as AnswerShippingQuery, but the request is bound to ctx.
+func (client *Client) AnswerShippingQueryCtx(ctx context.Context, req *AnswerShippingQueryRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("answerShippingQuery", params)
+    apiResp, err := client.RequestCtx(ctx, "answerShippingQuery", params)
     if err != nil {
         return false, err
     }
@@ -1309,9 +1651,14 @@ func (client *Client) AnswerShippingQuery(req *AnswerShippingQueryRequest) (bool
 
 // Once the user has confirmed their payment and shipping details, the Bot API sends the final confirmation in the form of an Update with the field pre_checkout_query. Use this method to respond to such pre-checkout queries. On success, True is returned. Note: The Bot API must receive an answer within 10 seconds after the pre-checkout query was sent.
 func (client *Client) AnswerPreCheckoutQuery(req *AnswerPreCheckoutQueryRequest) (bool, error) {
+    return client.AnswerPreCheckoutQueryCtx(context.Background(), req)
+}
+
+// AnswerPreCheckoutQueryCtx is the same as AnswerPreCheckoutQuery, but the request is bound to ctx.
+func (client *Client) AnswerPreCheckoutQueryCtx(ctx context.Context, req *AnswerPreCheckoutQueryRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("answerPreCheckoutQuery", params)
+    apiResp, err := client.RequestCtx(ctx, "answerPreCheckoutQuery", params)
     if err != nil {
         return false, err
     }
@@ -1333,9 +1680,14 @@ func (client *Client) AnswerPreCheckoutQuery(req *AnswerPreCheckoutQueryRequest)
 // Informs a user that some of the Telegram Passport elements they provided contains errors. The user will not be able to re-submit their Passport to you until the errors are fixed (the contents of the field for which you returned the error must change). Returns True on success.
 //Use this if the data submitted by the user doesn't satisfy the standards your service requires for any reason. For example, if a birthday date seems invalid, a submitted document is blurry, a scan shows evidence of tampering, etc. Supply some details in the error message to make sure the user knows how to correct the issues.
 func (client *Client) SetPassportDataErrors(req *SetPassportDataErrorsRequest) (bool, error) {
+    return client.SetPassportDataErrorsCtx(context.Background(), req)
+}
+
+// SetPassportDataErrorsCtx is the same as SetPassportDataErrors, but the request is bound to ctx.
+func (client *Client) SetPassportDataErrorsCtx(ctx context.Context, req *SetPassportDataErrorsRequest) (bool, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setPassportDataErrors", params)
+    apiResp, err := client.RequestCtx(ctx, "setPassportDataErrors", params)
     if err != nil {
         return false, err
     }
@@ -1356,9 +1708,14 @@ func (client *Client) SetPassportDataErrors(req *SetPassportDataErrorsRequest) (
 
 // Use this method to send a game. On success, the sent Message is returned.
 func (client *Client) SendGame(req *SendGameRequest) (*Message, error) {
+    return client.SendGameCtx(context.Background(), req)
+}
+
+// SendGameCtx is the same as SendGame, but the request is bound to ctx.
+func (client *Client) SendGameCtx(ctx context.Context, req *SendGameRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("sendGame", params)
+    apiResp, err := client.RequestCtx(ctx, "sendGame", params)
     if err != nil {
         return nil, err
     }
@@ -1379,9 +1736,14 @@ func (client *Client) SendGame(req *SendGameRequest) (*Message, error) {
 
 // Use this method to set the score of the specified user in a game. On success, if the message was sent by the bot, returns the edited Message, otherwise returns True. Returns an error, if the new score is not greater than the user's current score in the chat and force is False.
 func (client *Client) SetGameScore(req *SetGameScoreRequest) (*Message, error) {
+    return client.SetGameScoreCtx(context.Background(), req)
+}
+
+// SetGameScoreCtx is the same as SetGameScore, but the request is bound to ctx.
+func (client *Client) SetGameScoreCtx(ctx context.Context, req *SetGameScoreRequest) (*Message, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("setGameScore", params)
+    apiResp, err := client.RequestCtx(ctx, "setGameScore", params)
     if err != nil {
         return nil, err
     }
@@ -1390,6 +1752,11 @@ func (client *Client) SetGameScore(req *SetGameScoreRequest) (*Message, error) {
         return nil, newError(apiResp)
     }
 
+    // the result of inline messages is true
+    if isTrueResult(apiResp) {
+        return nil, nil
+    }
+
     var resp *Message
 
     err = json.Unmarshal(apiResp.Result, &resp)
@@ -1402,9 +1769,14 @@ func (client *Client) SetGameScore(req *SetGameScoreRequest) (*Message, error) {
 
 // Use this method to get data for high score tables. Will return the score of the specified user and several of his neighbors in a game. On success, returns an Array of GameHighScore objects.
 func (client *Client) GetGameHighScores(req *GetGameHighScoresRequest) ([]*GameHighScore, error) {
+    return client.GetGameHighScoresCtx(context.Background(), req)
+}
+
+// GetGameHighScoresCtx is the same as GetGameHighScores, but the request is bound to ctx.
+func (client *Client) GetGameHighScoresCtx(ctx context.Context, req *GetGameHighScoresRequest) ([]*GameHighScore, error) {
     params := requestToMap(req)
 
-    apiResp, err := client.Request("getGameHighScores", params)
+    apiResp, err := client.RequestCtx(ctx, "getGameHighScores", params)
     if err != nil {
         return nil, err
     }
@@ -0,0 +1,157 @@
+package client
+
+import (
+    "context"
+    "strconv"
+    "sync"
+)
+
+// MigrationStore keeps group to supergroup migrations: old chat id -> new chat id.
+type MigrationStore interface {
+    Load(chatId int64) (int64, bool)
+    Save(oldChatId int64, newChatId int64)
+}
+
+type MigrationHandler func(oldChatId int64, newChatId int64)
+
+// WithMigration makes the client follow group to supergroup migrations.
+// If an api call fails because the group was migrated, the mapping is saved to the store, reported to onMigrate
+// and the call is repeated with the new chat id. Later calls to the old chat id go straight to the new one.
+// The store defaults to NewMemoryMigrationStore(), onMigrate may be nil.
+func WithMigration(store MigrationStore, onMigrate MigrationHandler) Option {
+    return func(client *Client) {
+        if store == nil {
+            store = NewMemoryMigrationStore()
+        }
+
+        client.migration = &migration{
+            store:     store,
+            onMigrate: onMigrate,
+        }
+    }
+}
+
+type migration struct {
+    store     MigrationStore
+    onMigrate MigrationHandler
+}
+
+type requestFunc func(ctx context.Context, method string, params map[string]interface{}) (*ApiResponse, error)
+
+func (migration *migration) do(ctx context.Context, method string, params map[string]interface{}, request requestFunc) (*ApiResponse, error) {
+    params = migration.migrateParams(params)
+
+    resp, err := request(ctx, method, params)
+    if err != nil || resp.Ok || resp.Parameters == nil || resp.Parameters.MigrateToChatId == nil {
+        return resp, err
+    }
+
+    newChatId := *resp.Parameters.MigrateToChatId
+
+    oldChatId, ok := migration.migratedChatId(ctx, params, newChatId, request)
+    if !ok {
+        return resp, err
+    }
+
+    migration.store.Save(oldChatId, newChatId)
+
+    if migration.onMigrate != nil {
+        migration.onMigrate(oldChatId, newChatId)
+    }
+
+    if hasStreams(params) {
+        return resp, err
+    }
+
+    return request(ctx, method, migration.migrateParams(params))
+}
+
+// migratedChatId finds the param holding the migrated group: the error doesn't tell chat_id from from_chat_id.
+// Only basic groups migrate, so private chats, supergroups and channels are skipped. If both params are basic groups,
+// the one still migrating is found with getChat.
+func (migration *migration) migratedChatId(ctx context.Context, params map[string]interface{}, newChatId int64, request requestFunc) (int64, bool) {
+    chatIds := []int64{}
+
+    for _, key := range []string{"chat_id", "from_chat_id"} {
+        chatId, ok := toIntChatId(params[key])
+        if ok && (intChatId{chatId: chatId}).IsBasicGroup() {
+            chatIds = append(chatIds, chatId)
+        }
+    }
+
+    if len(chatIds) == 1 {
+        return chatIds[0], true
+    }
+
+    for _, chatId := range chatIds {
+        resp, err := request(ctx, "getChat", map[string]interface{}{"chat_id": IntChatId(chatId)})
+        if err == nil && !resp.Ok && resp.Parameters != nil && resp.Parameters.MigrateToChatId != nil && *resp.Parameters.MigrateToChatId == newChatId {
+            return chatId, true
+        }
+    }
+
+    return 0, false
+}
+
+func (migration *migration) migrateParams(params map[string]interface{}) map[string]interface{} {
+    migratedParams := make(map[string]interface{}, len(params))
+
+    for key, param := range params {
+        migratedParams[key] = param
+    }
+
+    for _, key := range []string{"chat_id", "from_chat_id"} {
+        chatId, ok := toIntChatId(params[key])
+        if !ok {
+            continue
+        }
+
+        newChatId, ok := migration.store.Load(chatId)
+        if ok {
+            migratedParams[key] = IntChatId(newChatId)
+        }
+    }
+
+    return migratedParams
+}
+
+func toIntChatId(param interface{}) (int64, bool) {
+    chatId, ok := param.(ChatId)
+    if !ok || chatId == nil {
+        return 0, false
+    }
+
+    intChatId, err := strconv.ParseInt(chatId.String(), 10, 64)
+    if err != nil {
+        return 0, false
+    }
+
+    return intChatId, true
+}
+
+func NewMemoryMigrationStore() MigrationStore {
+    return &memoryMigrationStore{
+        chatIds: make(map[int64]int64),
+    }
+}
+
+type memoryMigrationStore struct {
+    chatIds map[int64]int64
+    mu      sync.RWMutex
+}
+
+func (store *memoryMigrationStore) Load(chatId int64) (int64, bool) {
+    store.mu.RLock()
+    defer store.mu.RUnlock()
+
+    newChatId, ok := store.chatIds[chatId]
+
+    return newChatId, ok
+}
+
+func (store *memoryMigrationStore) Save(oldChatId int64, newChatId int64) {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    store.chatIds[oldChatId] = newChatId
+}
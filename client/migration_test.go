@@ -0,0 +1,101 @@
+package client_test
+
+import (
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestMigrationSendMessage(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    server.AddChat(grabottest.NewGroupChat(-5, "group"))
+    server.MigrateChat(-5, -1001234567890)
+
+    migrations := [][2]int64{}
+    apiClient := server.Client(client.WithMigration(nil, func(oldChatId int64, newChatId int64) {
+        migrations = append(migrations, [2]int64{oldChatId, newChatId})
+    }))
+
+    for i := 0; i < 2; i++ {
+        message, err := apiClient.SendMessage(&client.SendMessageRequest{
+            ChatId: client.IntChatId(-5),
+            Text:   "hello",
+        })
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if message.Chat.Id != -1001234567890 {
+            t.Fatalf("message is sent to %d", message.Chat.Id)
+        }
+    }
+
+    if len(migrations) != 1 || migrations[0] != [2]int64{-5, -1001234567890} {
+        t.Fatalf("wrong migrations: %v", migrations)
+    }
+}
+
+func TestMigrationForwardFromMigratedGroup(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    server.AddChat(grabottest.NewPrivateChat(user))
+    update := server.AddMessage(grabottest.NewGroupChat(-5, "group"), user, "hello")
+    server.MigrateChat(-5, -1001234567890)
+
+    migrations := [][2]int64{}
+    apiClient := server.Client(client.WithMigration(nil, func(oldChatId int64, newChatId int64) {
+        migrations = append(migrations, [2]int64{oldChatId, newChatId})
+    }))
+
+    // the message isn't in the supergroup, the repeated call fails: only the migration matters
+    apiClient.ForwardMessage(&client.ForwardMessageRequest{
+        ChatId:     client.IntChatId(user.Id),
+        FromChatId: client.IntChatId(-5),
+        MessageId:  update.Message.MessageId,
+    })
+
+    if len(migrations) != 1 || migrations[0] != [2]int64{-5, -1001234567890} {
+        t.Fatalf("wrong migrations: %v", migrations)
+    }
+
+    message, err := apiClient.SendMessage(&client.SendMessageRequest{
+        ChatId: client.IntChatId(user.Id),
+        Text:   "hello",
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if message.Chat.Id != user.Id {
+        t.Fatalf("private message is sent to %d", message.Chat.Id)
+    }
+}
+
+func TestMigrationBothChatsAreGroups(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    server.AddChat(grabottest.NewGroupChat(-7, "destination"))
+    update := server.AddMessage(grabottest.NewGroupChat(-5, "group"), user, "hello")
+    server.MigrateChat(-5, -1001234567890)
+
+    migrations := [][2]int64{}
+    apiClient := server.Client(client.WithMigration(nil, func(oldChatId int64, newChatId int64) {
+        migrations = append(migrations, [2]int64{oldChatId, newChatId})
+    }))
+
+    apiClient.ForwardMessage(&client.ForwardMessageRequest{
+        ChatId:     client.IntChatId(-7),
+        FromChatId: client.IntChatId(-5),
+        MessageId:  update.Message.MessageId,
+    })
+
+    if len(migrations) != 1 || migrations[0] != [2]int64{-5, -1001234567890} {
+        t.Fatalf("wrong migrations: %v", migrations)
+    }
+}
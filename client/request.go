@@ -26,6 +26,8 @@ type SetWebhookRequest struct {
     MaxConnections *int64 `json:"max_connections,omitempty" structs:"max_connections,omitempty,omitnested"`
     // List the types of updates you want your bot to receive. For example, specify [“message”, “edited_channel_post”, “callback_query”] to only receive updates of these types. See Update for a complete list of available update types. Specify an empty list to receive all updates regardless of type (default). If not specified, the previous setting will be used.Please note that this parameter doesn't affect updates created before the call to the setWebhook, so unwanted updates may be received for a short period of time.
     AllowedUpdates *[]UpdateType `json:"allowed_updates,omitempty" structs:"allowed_updates,omitempty,omitnested"`
+    // A secret token to be sent in a header “X-Telegram-Bot-Api-Secret-Token” in every webhook request, 1-256 characters. Only characters A-Z, a-z, 0-9, _ and - are allowed. The header is useful to ensure that the request comes from a webhook set by you.
+    SecretToken *string `json:"secret_token,omitempty" structs:"secret_token,omitempty,omitnested"`
 }
 
 // Use this method to send text messages. On success, the sent Message is returned.
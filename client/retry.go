@@ -0,0 +1,130 @@
+package client
+
+import (
+    "context"
+    "math/rand"
+    "net/url"
+    "time"
+)
+
+// RetryPolicy repeats failed api calls: transport errors, flood control (429) and server errors (5xx).
+// Other api errors (400, 403, ...) are never retried.
+// Requests uploading streams (FileInputFile) are not retried because their readers can't be rewound.
+type RetryPolicy struct {
+    // Total number of attempts including the first one. Defaults to 3.
+    MaxAttempts int
+    // Backoff before the second attempt. It doubles for each following attempt. Defaults to 1 second.
+    MinBackoff time.Duration
+    // Upper bound of the backoff. Defaults to 30 seconds. Parameters.RetryAfter of 429 responses is honored as is.
+    MaxBackoff time.Duration
+    // Called before each retry, e.g. to log or meter it.
+    OnRetry func(method string, attempt int, err error, delay time.Duration)
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+    return func(client *Client) {
+        if policy.MaxAttempts <= 0 {
+            policy.MaxAttempts = 3
+        }
+
+        if policy.MinBackoff <= 0 {
+            policy.MinBackoff = time.Second
+        }
+
+        if policy.MaxBackoff <= 0 {
+            policy.MaxBackoff = 30 * time.Second
+        }
+
+        client.retryPolicy = &policy
+    }
+}
+
+func (policy *RetryPolicy) do(ctx context.Context, method string, request func() (*ApiResponse, error)) (*ApiResponse, error) {
+    for attempt := 1; ; attempt++ {
+        resp, err := request()
+
+        retryErr, retryAfter, retryable := checkRetryable(resp, err)
+        if !retryable || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+            return resp, err
+        }
+
+        delay := retryAfter
+        if delay == 0 {
+            delay = policy.backoff(attempt)
+        }
+
+        if policy.OnRetry != nil {
+            policy.OnRetry(method, attempt, retryErr, delay)
+        }
+
+        timer := time.NewTimer(delay)
+        select {
+        case <-timer.C:
+
+        case <-ctx.Done():
+            timer.Stop()
+            return nil, ctx.Err()
+        }
+    }
+}
+
+// backoff is exponential with "equal jitter": a random duration in [delay/2, delay].
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+    delay := policy.MinBackoff
+    for i := 1; i < attempt && delay < policy.MaxBackoff; i++ {
+        delay *= 2
+    }
+
+    if delay > policy.MaxBackoff {
+        delay = policy.MaxBackoff
+    }
+
+    half := int64(delay / 2)
+
+    return time.Duration(half + rand.Int63n(half+1))
+}
+
+func checkRetryable(resp *ApiResponse, err error) (error, time.Duration, bool) {
+    if err != nil {
+        switch err := err.(type) {
+        case *url.Error:
+            return err, 0, true
+
+        case *HttpError:
+            return err, 0, err.StatusCode == 429 || err.StatusCode >= 500
+        }
+
+        return err, 0, false
+    }
+
+    if resp.Ok || resp.ErrorCode == nil {
+        return nil, 0, false
+    }
+
+    code := *resp.ErrorCode
+
+    if code == 429 {
+        var retryAfter time.Duration
+        if resp.Parameters != nil && resp.Parameters.RetryAfter != nil {
+            retryAfter = time.Duration(*resp.Parameters.RetryAfter) * time.Second
+        }
+        return newError(resp), retryAfter, true
+    }
+
+    if code >= 500 {
+        return newError(resp), 0, true
+    }
+
+    return nil, 0, false
+}
+
+func hasStreams(params map[string]interface{}) bool {
+    for _, param := range params {
+        inputFile, ok := param.(InputFile)
+        if ok && inputFile.IsStream() {
+            return true
+        }
+    }
+
+    return false
+}
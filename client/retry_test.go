@@ -0,0 +1,83 @@
+package client
+
+import (
+    "errors"
+    "net/url"
+    "testing"
+    "time"
+)
+
+func TestCheckRetryable(t *testing.T) {
+    apiResponse := func(code int64, retryAfter int64) *ApiResponse {
+        resp := &ApiResponse{
+            ErrorCode:   &code,
+            Description: OptionalString("error"),
+        }
+
+        if retryAfter > 0 {
+            resp.Parameters = &ResponseParameters{RetryAfter: &retryAfter}
+        }
+
+        return resp
+    }
+
+    tests := []struct {
+        name       string
+        resp       *ApiResponse
+        err        error
+        retryAfter time.Duration
+        retryable  bool
+    }{
+        {"ok", &ApiResponse{Ok: true}, nil, 0, false},
+        {"transport error", nil, &url.Error{Op: "Post", URL: "http://localhost", Err: errors.New("connection refused")}, 0, true},
+        {"other error", nil, errors.New("json error"), 0, false},
+        {"http 502", nil, &HttpError{StatusCode: 502}, 0, true},
+        {"http 429", nil, &HttpError{StatusCode: 429}, 0, true},
+        {"http 404", nil, &HttpError{StatusCode: 404}, 0, false},
+        {"429 with retry after", apiResponse(429, 5), nil, 5 * time.Second, true},
+        {"429", apiResponse(429, 0), nil, 0, true},
+        {"500", apiResponse(500, 0), nil, 0, true},
+        {"400", apiResponse(400, 0), nil, 0, false},
+        {"403", apiResponse(403, 0), nil, 0, false},
+    }
+
+    for _, test := range tests {
+        err, retryAfter, retryable := checkRetryable(test.resp, test.err)
+
+        if retryable != test.retryable || retryAfter != test.retryAfter {
+            t.Errorf("%s: retryable %t after %s, %t after %s expected", test.name, retryable, retryAfter, test.retryable, test.retryAfter)
+        }
+
+        if retryable && err == nil {
+            t.Errorf("%s: no error to report", test.name)
+        }
+    }
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+    policy := &RetryPolicy{
+        MinBackoff: time.Second,
+        MaxBackoff: 10 * time.Second,
+    }
+
+    tests := []struct {
+        attempt int
+        max     time.Duration
+    }{
+        {1, time.Second},
+        {2, 2 * time.Second},
+        {3, 4 * time.Second},
+        {4, 8 * time.Second},
+        {5, 10 * time.Second},
+        {50, 10 * time.Second},
+    }
+
+    for _, test := range tests {
+        for i := 0; i < 100; i++ {
+            delay := policy.backoff(test.attempt)
+            if delay < test.max/2 || delay > test.max {
+                t.Fatalf("backoff(%d) = %s, [%s, %s] expected", test.attempt, delay, test.max/2, test.max)
+            }
+        }
+    }
+}
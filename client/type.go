@@ -1,6 +1,8 @@
 package client
 
 import (
+    "encoding/json"
+    "errors"
     "io"
     "strings"
     "os"
@@ -663,6 +665,10 @@ func (inputFile *FileIdInputFile) IsStream() bool {
     return false
 }
 
+func (inputFile *FileIdInputFile) MarshalJSON() ([]byte, error) {
+    return json.Marshal(inputFile.FileId)
+}
+
 // Provide Telegram with an HTTP URL for the file to be sent. Telegram will download and send the file. 5 MB max size for photos and 20 MB max for other types of content.
 type UrlInputFile struct {
     Url string
@@ -688,6 +694,10 @@ func (inputFile *UrlInputFile) IsStream() bool {
     return false
 }
 
+func (inputFile *UrlInputFile) MarshalJSON() ([]byte, error) {
+    return json.Marshal(inputFile.Url)
+}
+
 // Post the file using multipart/form-data in the usual way that files are uploaded via the browser. 10 MB max size for photos, 50 MB for other files.
 type FileInputFile struct {
     Reader   io.Reader
@@ -730,6 +740,11 @@ func (inputFile *FileInputFile) IsStream() bool {
     return true
 }
 
+// Streams can be uploaded with multipart/form-data only.
+func (inputFile *FileInputFile) MarshalJSON() ([]byte, error) {
+    return nil, errors.New("file stream can't be encoded as json")
+}
+
 // This object represents a sticker.
 type Sticker struct {
     // Unique identifier for this file
@@ -0,0 +1,78 @@
+package client
+
+// UpdateTypeOf returns the type of the update, i.e. the name of its filled field.
+func UpdateTypeOf(update *Update) UpdateType {
+    switch {
+    case update.Message != nil:
+        return UpdateTypeMessage
+    case update.EditedMessage != nil:
+        return UpdateTypeEditedMessage
+    case update.ChannelPost != nil:
+        return UpdateTypeChannelPost
+    case update.EditedChannelPost != nil:
+        return UpdateTypeEditedChannelPost
+    case update.InlineQuery != nil:
+        return UpdateTypeInlineQuery
+    case update.ChosenInlineResult != nil:
+        return UpdateTypeChosenInlineResult
+    case update.CallbackQuery != nil:
+        return UpdateTypeCallbackQuery
+    case update.ShippingQuery != nil:
+        return UpdateTypeShippingQuery
+    case update.PreCheckoutQuery != nil:
+        return UpdateTypePreCheckoutQuery
+    }
+
+    return ""
+}
+
+// UpdateMessage returns the message, the edited message, the channel post or the edited channel post of the update.
+func UpdateMessage(update *Update) *Message {
+    switch {
+    case update.Message != nil:
+        return update.Message
+    case update.EditedMessage != nil:
+        return update.EditedMessage
+    case update.ChannelPost != nil:
+        return update.ChannelPost
+    case update.EditedChannelPost != nil:
+        return update.EditedChannelPost
+    }
+
+    return nil
+}
+
+// UpdateChat returns the chat the update comes from, the chat of a callback query is the chat of its message.
+// It's nil for inline queries and payments.
+func UpdateChat(update *Update) *Chat {
+    message := UpdateMessage(update)
+    if message == nil && update.CallbackQuery != nil {
+        message = update.CallbackQuery.Message
+    }
+
+    if message == nil {
+        return nil
+    }
+
+    return &message.Chat
+}
+
+// UpdateUser returns the sender of the update, nil for channel posts.
+func UpdateUser(update *Update) *User {
+    switch {
+    case UpdateMessage(update) != nil:
+        return UpdateMessage(update).From
+    case update.InlineQuery != nil:
+        return &update.InlineQuery.From
+    case update.ChosenInlineResult != nil:
+        return &update.ChosenInlineResult.From
+    case update.CallbackQuery != nil:
+        return &update.CallbackQuery.From
+    case update.ShippingQuery != nil:
+        return &update.ShippingQuery.From
+    case update.PreCheckoutQuery != nil:
+        return &update.PreCheckoutQuery.From
+    }
+
+    return nil
+}
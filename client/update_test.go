@@ -0,0 +1,45 @@
+package client_test
+
+import (
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestUpdateHelpers(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewGroupChat(-100, "group")
+
+    message := server.AddMessage(chat, user, "hello")
+    callbackQuery := server.AddCallbackQuery(user, message.Message, "data")
+    inlineQuery := server.AddInlineQuery(user, "query")
+
+    tests := []struct {
+        update     *client.Update
+        updateType client.UpdateType
+        chatId     int64
+    }{
+        {message, client.UpdateTypeMessage, chat.Id},
+        {callbackQuery, client.UpdateTypeCallbackQuery, chat.Id},
+        {inlineQuery, client.UpdateTypeInlineQuery, 0},
+    }
+
+    for _, test := range tests {
+        if client.UpdateTypeOf(test.update) != test.updateType {
+            t.Errorf("update #%d: type %s, %s expected", test.update.UpdateId, client.UpdateTypeOf(test.update), test.updateType)
+        }
+
+        updateChat := client.UpdateChat(test.update)
+        if (updateChat == nil && test.chatId != 0) || (updateChat != nil && updateChat.Id != test.chatId) {
+            t.Errorf("update #%d: wrong chat %+v", test.update.UpdateId, updateChat)
+        }
+
+        updateUser := client.UpdateUser(test.update)
+        if updateUser == nil || updateUser.Id != user.Id {
+            t.Errorf("update #%d: wrong user %+v", test.update.UpdateId, updateUser)
+        }
+    }
+}
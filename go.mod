@@ -1,5 +1,7 @@
 module github.com/zelenin/grabot
 
+go 1.18
+
 require (
 	github.com/fatih/structs v0.0.0-20180123065059-ebf56d35bba7
 	github.com/mxmCherry/multipartbuilder v1.0.0
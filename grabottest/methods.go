@@ -0,0 +1,832 @@
+package grabottest
+
+import (
+    "fmt"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+type methodHandler func(server *Server, params *params) (interface{}, *client.ApiResponse)
+
+func methodHandlers() map[string]methodHandler {
+    handlers := map[string]methodHandler{
+        "getme":                   getMe,
+        "setwebhook":              setWebhook,
+        "deletewebhook":           deleteWebhook,
+        "getwebhookinfo":          getWebhookInfo,
+        "sendmessage":             sendMessage,
+        "forwardmessage":          forwardMessage,
+        "sendphoto":               sendPhoto,
+        "sendaudio":               sendAudio,
+        "senddocument":            sendDocument,
+        "sendvideo":               sendVideo,
+        "sendanimation":           sendAnimation,
+        "sendvoice":               sendVoice,
+        "sendvideonote":           sendVideoNote,
+        "sendmediagroup":          sendMediaGroup,
+        "sendlocation":            sendLocation,
+        "editmessagelivelocation": editMessageLiveLocation,
+        "stopmessagelivelocation": stopMessageLiveLocation,
+        "sendvenue":               sendVenue,
+        "sendcontact":             sendContact,
+        "sendchataction":          chatMethod,
+        "getuserprofilephotos":    getUserProfilePhotos,
+        "getfile":                 getFile,
+        "kickchatmember":          kickChatMember,
+        "unbanchatmember":         unbanChatMember,
+        "restrictchatmember":      restrictChatMember,
+        "promotechatmember":       promoteChatMember,
+        "exportchatinvitelink":    exportChatInviteLink,
+        "setchatphoto":            chatMethod,
+        "deletechatphoto":         chatMethod,
+        "setchattitle":            setChatTitle,
+        "setchatdescription":      setChatDescription,
+        "pinchatmessage":          pinChatMessage,
+        "unpinchatmessage":        unpinChatMessage,
+        "leavechat":               chatMethod,
+        "getchat":                 getChat,
+        "getchatadministrators":   getChatAdministrators,
+        "getchatmemberscount":     getChatMembersCount,
+        "getchatmember":           getChatMember,
+        "setchatstickerset":       setChatStickerSet,
+        "deletechatstickerset":    deleteChatStickerSet,
+        "answercallbackquery":     answerCallbackQuery,
+        "editmessagetext":         editMessageText,
+        "editmessagecaption":      editMessageCaption,
+        "editmessagemedia":        editMessageMedia,
+        "editmessagereplymarkup":  editMessageReplyMarkup,
+        "deletemessage":           deleteMessage,
+        "sendsticker":             sendSticker,
+        "getstickerset":           getStickerSet,
+        "uploadstickerfile":       uploadStickerFile,
+        "createnewstickerset":     alwaysTrue,
+        "addstickertoset":         alwaysTrue,
+        "setstickerpositioninset": alwaysTrue,
+        "deletestickerfromset":    alwaysTrue,
+        "answerinlinequery":       alwaysTrue,
+        "sendinvoice":             sendInvoice,
+        "answershippingquery":     alwaysTrue,
+        "answerprecheckoutquery":  alwaysTrue,
+        "setpassportdataerrors":   alwaysTrue,
+        "sendgame":                sendGame,
+        "setgamescore":            setGameScore,
+        "getgamehighscores":       getGameHighScores,
+    }
+
+    return handlers
+}
+
+func alwaysTrue(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return true, nil
+}
+
+func chatMethod(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    _, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    return true, nil
+}
+
+func getMe(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return server.Bot, nil
+}
+
+func setWebhook(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    url := params.string("url")
+    if url == "" {
+        server.webhook = nil
+        return true, nil
+    }
+
+    webhook := &webhook{
+        url:            url,
+        hasCertificate: params.has("certificate"),
+        maxConnections: 40,
+        secretToken:    params.string("secret_token"),
+    }
+
+    if params.has("max_connections") {
+        webhook.maxConnections = params.int("max_connections")
+    }
+
+    if params.has("allowed_updates") {
+        params.json("allowed_updates", &webhook.allowedUpdates)
+    }
+
+    server.webhook = webhook
+
+    return true, nil
+}
+
+func deleteWebhook(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    server.webhook = nil
+
+    return true, nil
+}
+
+func getWebhookInfo(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    webhookInfo := &client.WebhookInfo{
+        PendingUpdateCount: int64(len(server.updates)),
+    }
+
+    webhook := server.webhook
+    if webhook != nil {
+        webhookInfo.Url = webhook.url
+        webhookInfo.HasCustomCertificate = webhook.hasCertificate
+        webhookInfo.MaxConnections = client.OptionalInt(webhook.maxConnections)
+        webhookInfo.LastErrorDate = webhook.lastErrorDate
+        webhookInfo.LastErrorMessage = webhook.lastError
+        if webhook.allowedUpdates != nil {
+            allowedUpdates := append([]string{}, webhook.allowedUpdates...)
+            webhookInfo.AllowedUpdates = &allowedUpdates
+        }
+    }
+
+    return webhookInfo, nil
+}
+
+func sendMessage(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    text := params.string("text")
+    if text == "" {
+        return nil, errorResponse(400, "Bad Request: message text is empty", nil)
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Text = client.OptionalString(text)
+
+    return server.addMessage(message), nil
+}
+
+func forwardMessage(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    fromChat, apiErr := server.chatByParam(params.string("from_chat_id"))
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    original, ok := server.messages[fromChat.Id][params.int("message_id")]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: message to forward not found", nil)
+    }
+
+    message := *original
+    message.MessageId = 0
+    message.Date = 0
+    message.Chat = *chat
+    message.From = &server.Bot
+    message.ReplyToMessage = nil
+    message.ForwardDate = client.OptionalInt(original.Date)
+
+    if fromChat.Type == "channel" {
+        message.ForwardFromChat = fromChat
+        message.ForwardFromMessageId = client.OptionalInt(original.MessageId)
+    } else {
+        message.ForwardFrom = original.From
+    }
+
+    return server.addMessage(&message), nil
+}
+
+// sendFile is the common part of the send* methods with a file param.
+func sendFile(server *Server, params *params, key string, fill func(message *client.Message, file *file)) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    file, apiErr := server.inputFile(params, key)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Caption = params.optionalString("caption")
+
+    fill(message, file)
+
+    return server.addMessage(message), nil
+}
+
+func fileSize(file *file) *int64 {
+    return client.OptionalInt(int64(len(file.data)))
+}
+
+func sendPhoto(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "photo", func(message *client.Message, file *file) {
+        message.Photo = &[]client.PhotoSize{
+            {FileId: file.id, Width: 640, Height: 480, FileSize: fileSize(file)},
+        }
+    })
+}
+
+func sendAudio(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "audio", func(message *client.Message, file *file) {
+        message.Audio = &client.Audio{
+            FileId:    file.id,
+            Duration:  params.int("duration"),
+            Performer: params.optionalString("performer"),
+            Title:     params.optionalString("title"),
+            FileSize:  fileSize(file),
+        }
+    })
+}
+
+func sendDocument(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "document", func(message *client.Message, file *file) {
+        message.Document = &client.Document{
+            FileId:   file.id,
+            FileName: client.OptionalString(file.name),
+            FileSize: fileSize(file),
+        }
+    })
+}
+
+func sendVideo(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "video", func(message *client.Message, file *file) {
+        message.Video = &client.Video{
+            FileId:   file.id,
+            Width:    params.int("width"),
+            Height:   params.int("height"),
+            Duration: params.int("duration"),
+            FileSize: fileSize(file),
+        }
+    })
+}
+
+func sendAnimation(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "animation", func(message *client.Message, file *file) {
+        message.Animation = &client.Animation{
+            FileId:   file.id,
+            Width:    params.int("width"),
+            Height:   params.int("height"),
+            Duration: params.int("duration"),
+            FileName: client.OptionalString(file.name),
+            FileSize: fileSize(file),
+        }
+        message.Document = &client.Document{
+            FileId:   file.id,
+            FileName: client.OptionalString(file.name),
+            FileSize: fileSize(file),
+        }
+    })
+}
+
+func sendVoice(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "voice", func(message *client.Message, file *file) {
+        message.Voice = &client.Voice{
+            FileId:   file.id,
+            Duration: params.int("duration"),
+            FileSize: fileSize(file),
+        }
+    })
+}
+
+func sendVideoNote(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "video_note", func(message *client.Message, file *file) {
+        message.VideoNote = &client.VideoNote{
+            FileId:   file.id,
+            Length:   params.int("length"),
+            Duration: params.int("duration"),
+            FileSize: fileSize(file),
+        }
+    })
+}
+
+func sendSticker(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return sendFile(server, params, "sticker", func(message *client.Message, file *file) {
+        message.Caption = nil
+        message.Sticker = &client.Sticker{
+            FileId:   file.id,
+            Width:    512,
+            Height:   512,
+            FileSize: fileSize(file),
+        }
+    })
+}
+
+func sendMediaGroup(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    var media []struct {
+        Type    string  `json:"type"`
+        Media   string  `json:"media"`
+        Caption *string `json:"caption"`
+    }
+
+    err := params.json("media", &media)
+    if err != nil || len(media) < 2 || len(media) > 10 {
+        return nil, errorResponse(400, "Bad Request: wrong number of media specified", nil)
+    }
+
+    mediaGroupId := client.OptionalString(fmt.Sprintf("%d", server.nextId()))
+
+    messages := []*client.Message{}
+    for _, inputMedia := range media {
+        file, ok := server.files[inputMedia.Media]
+        if !ok {
+            file = server.addFile(inputMedia.Media, nil)
+        }
+
+        message := server.newBotMessage(chat, params)
+        message.MediaGroupId = mediaGroupId
+        message.Caption = inputMedia.Caption
+
+        if inputMedia.Type == "video" {
+            message.Video = &client.Video{FileId: file.id, FileSize: fileSize(file)}
+        } else {
+            message.Photo = &[]client.PhotoSize{{FileId: file.id, Width: 640, Height: 480, FileSize: fileSize(file)}}
+        }
+
+        messages = append(messages, server.addMessage(message))
+    }
+
+    return messages, nil
+}
+
+func sendLocation(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Location = &client.Location{
+        Latitude:  params.float("latitude"),
+        Longitude: params.float("longitude"),
+    }
+
+    return server.addMessage(message), nil
+}
+
+func editMessageLiveLocation(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    if message.Location == nil {
+        return nil, errorResponse(400, "Bad Request: message can't be edited", nil)
+    }
+
+    message.Location = &client.Location{
+        Latitude:  params.float("latitude"),
+        Longitude: params.float("longitude"),
+    }
+    message.EditDate = client.OptionalInt(time.Now().Unix())
+
+    return message, nil
+}
+
+func stopMessageLiveLocation(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    return message, nil
+}
+
+func sendVenue(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Venue = &client.Venue{
+        Location: client.Location{
+            Latitude:  params.float("latitude"),
+            Longitude: params.float("longitude"),
+        },
+        Title:        params.string("title"),
+        Address:      params.string("address"),
+        FoursquareId: params.optionalString("foursquare_id"),
+    }
+    message.Location = &message.Venue.Location
+
+    return server.addMessage(message), nil
+}
+
+func sendContact(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Contact = &client.Contact{
+        PhoneNumber: params.string("phone_number"),
+        FirstName:   params.string("first_name"),
+        LastName:    params.optionalString("last_name"),
+        Vcard:       params.optionalString("vcard"),
+    }
+
+    return server.addMessage(message), nil
+}
+
+func getUserProfilePhotos(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return &client.UserProfilePhotos{
+        TotalCount: 0,
+        Photos:     [][]client.PhotoSize{},
+    }, nil
+}
+
+func getFile(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    file, ok := server.files[params.string("file_id")]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: invalid file id", nil)
+    }
+
+    return &client.File{
+        FileId:   file.id,
+        FileSize: fileSize(file),
+        FilePath: client.OptionalString(file.path),
+    }, nil
+}
+
+func memberByParams(server *Server, params *params) (*client.ChatMember, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    userId := params.int("user_id")
+
+    member, ok := server.members[chat.Id][userId]
+    if ok {
+        return member, nil
+    }
+
+    return server.member(chat.Id, client.User{Id: userId, FirstName: fmt.Sprintf("User %d", userId)}), nil
+}
+
+func kickChatMember(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    member, apiErr := memberByParams(server, params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    member.Status = "kicked"
+    if params.has("until_date") {
+        member.UntilDate = client.OptionalInt(params.int("until_date"))
+    }
+
+    return true, nil
+}
+
+func unbanChatMember(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    member, apiErr := memberByParams(server, params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    if member.Status == "kicked" {
+        member.Status = "left"
+        member.UntilDate = nil
+    }
+
+    return true, nil
+}
+
+func restrictChatMember(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    member, apiErr := memberByParams(server, params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    member.Status = "restricted"
+    if params.has("until_date") {
+        member.UntilDate = client.OptionalInt(params.int("until_date"))
+    }
+
+    return true, nil
+}
+
+func promoteChatMember(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    member, apiErr := memberByParams(server, params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    member.Status = "administrator"
+
+    return true, nil
+}
+
+func exportChatInviteLink(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    inviteLink := fmt.Sprintf("https://t.me/joinchat/%d%d", -chat.Id, server.nextId())
+    chat.InviteLink = client.OptionalString(inviteLink)
+
+    return inviteLink, nil
+}
+
+func setChatTitle(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    chat.Title = client.OptionalString(params.string("title"))
+
+    return true, nil
+}
+
+func setChatDescription(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    chat.Description = params.optionalString("description")
+
+    return true, nil
+}
+
+func pinChatMessage(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message, ok := server.messages[chat.Id][params.int("message_id")]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: message to pin not found", nil)
+    }
+
+    chat.PinnedMessage = message
+
+    return true, nil
+}
+
+func unpinChatMessage(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    chat.PinnedMessage = nil
+
+    return true, nil
+}
+
+func getChat(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.chatByParam(params.string("chat_id"))
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    return chat, nil
+}
+
+func getChatAdministrators(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.chatByParam(params.string("chat_id"))
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    administrators := []*client.ChatMember{}
+    for _, member := range server.members[chat.Id] {
+        if member.Status == "creator" || member.Status == "administrator" {
+            administrators = append(administrators, member)
+        }
+    }
+
+    return administrators, nil
+}
+
+func getChatMembersCount(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.chatByParam(params.string("chat_id"))
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    count := 0
+    for _, member := range server.members[chat.Id] {
+        if member.Status != "left" && member.Status != "kicked" {
+            count++
+        }
+    }
+
+    return count, nil
+}
+
+func getChatMember(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.chatByParam(params.string("chat_id"))
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    member, ok := server.members[chat.Id][params.int("user_id")]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: user not found", nil)
+    }
+
+    return member, nil
+}
+
+func setChatStickerSet(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    chat.StickerSetName = client.OptionalString(params.string("sticker_set_name"))
+
+    return true, nil
+}
+
+func deleteChatStickerSet(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    chat.StickerSetName = nil
+
+    return true, nil
+}
+
+func answerCallbackQuery(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    answer, ok := server.callbackQueries[params.string("callback_query_id")]
+    if !ok || answer.Answered {
+        return nil, errorResponse(400, "Bad Request: query is too old and response timeout expired or query ID is invalid", nil)
+    }
+
+    answer.Answered = true
+    answer.Text = params.optionalString("text")
+    answer.ShowAlert = params.bool("show_alert")
+    answer.Url = params.optionalString("url")
+
+    return true, nil
+}
+
+func editMessageText(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    text := params.string("text")
+    if message.Text == nil {
+        return nil, errorResponse(400, "Bad Request: there is no text in the message to edit", nil)
+    }
+
+    if *message.Text == text && !params.has("reply_markup") {
+        return nil, errorResponse(400, "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message", nil)
+    }
+
+    message.Text = client.OptionalString(text)
+    message.EditDate = client.OptionalInt(time.Now().Unix())
+
+    return message, nil
+}
+
+func editMessageCaption(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    caption := params.optionalString("caption")
+    if message.Caption != nil && caption != nil && *message.Caption == *caption && !params.has("reply_markup") {
+        return nil, errorResponse(400, "Bad Request: message is not modified", nil)
+    }
+
+    message.Caption = caption
+    message.EditDate = client.OptionalInt(time.Now().Unix())
+
+    return message, nil
+}
+
+func editMessageMedia(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    message.EditDate = client.OptionalInt(time.Now().Unix())
+
+    return message, nil
+}
+
+func editMessageReplyMarkup(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    message.EditDate = client.OptionalInt(time.Now().Unix())
+
+    return message, nil
+}
+
+func deleteMessage(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    messageId := params.int("message_id")
+
+    _, ok := server.messages[chat.Id][messageId]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: message to delete not found", nil)
+    }
+
+    delete(server.messages[chat.Id], messageId)
+
+    return true, nil
+}
+
+func getStickerSet(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return &client.StickerSet{
+        Name:     params.string("name"),
+        Title:    params.string("name"),
+        Stickers: []client.Sticker{},
+    }, nil
+}
+
+func uploadStickerFile(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    file, apiErr := server.inputFile(params, "png_sticker")
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    return &client.File{
+        FileId:   file.id,
+        FileSize: fileSize(file),
+        FilePath: client.OptionalString(file.path),
+    }, nil
+}
+
+func sendInvoice(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    var prices []client.LabeledPrice
+    params.json("prices", &prices)
+
+    var totalAmount int64
+    for _, price := range prices {
+        totalAmount += price.Amount
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Invoice = &client.Invoice{
+        Title:          params.string("title"),
+        Description:    params.string("description"),
+        StartParameter: params.string("start_parameter"),
+        Currency:       params.string("currency"),
+        TotalAmount:    totalAmount,
+    }
+
+    return server.addMessage(message), nil
+}
+
+func sendGame(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message := server.newBotMessage(chat, params)
+    message.Game = &client.Game{
+        Title: params.string("game_short_name"),
+    }
+
+    return server.addMessage(message), nil
+}
+
+func setGameScore(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    message, apiErr := server.targetMessage(params)
+    if apiErr != nil || message == nil {
+        return true, apiErr
+    }
+
+    return message, nil
+}
+
+func getGameHighScores(server *Server, params *params) (interface{}, *client.ApiResponse) {
+    return []*client.GameHighScore{}, nil
+}
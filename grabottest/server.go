@@ -0,0 +1,441 @@
+package grabottest
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "sync"
+    "github.com/zelenin/grabot/client"
+)
+
+// Token is accepted by client.New, so tests don't need a real one.
+const Token = "123456789:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// Server is an in-process fake of the Telegram Bot API.
+//
+// It keeps an in-memory model of chats, messages, files and callback queries, serves updates for long polling
+// and webhooks, records every api call and can simulate api errors.
+type Server struct {
+    Bot client.User
+
+    httpServer *httptest.Server
+    handlers   map[string]methodHandler
+
+    mu              sync.Mutex
+    calls           []Call
+    failures        []*failure
+    blocked         map[int64]bool
+    migrations      map[int64]int64
+    chats           map[int64]*client.Chat
+    members         map[int64]map[int64]*client.ChatMember
+    messages        map[int64]map[int64]*client.Message
+    lastMessageIds  map[int64]int64
+    files           map[string]*file
+    callbackQueries map[string]*CallbackAnswer
+    updates         []*client.Update
+    lastUpdateId    int64
+    newUpdates      chan struct{}
+    webhook         *webhook
+    lastId          int64
+}
+
+func NewServer() *Server {
+    server := &Server{
+        Bot: client.User{
+            Id:        123456789,
+            IsBot:     true,
+            FirstName: "Grabot",
+            Username:  client.OptionalString("GrabotTestBot"),
+        },
+        blocked:         make(map[int64]bool),
+        migrations:      make(map[int64]int64),
+        chats:           make(map[int64]*client.Chat),
+        members:         make(map[int64]map[int64]*client.ChatMember),
+        messages:        make(map[int64]map[int64]*client.Message),
+        lastMessageIds:  make(map[int64]int64),
+        files:           make(map[string]*file),
+        callbackQueries: make(map[string]*CallbackAnswer),
+        newUpdates:      make(chan struct{}),
+    }
+
+    server.handlers = methodHandlers()
+    server.httpServer = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+    return server
+}
+
+// Url is the base url to pass to client.WithBaseUrl.
+func (server *Server) Url() string {
+    return server.httpServer.URL
+}
+
+// Client returns a client bound to the server.
+func (server *Server) Client(options ...client.Option) *client.Client {
+    options = append([]client.Option{client.WithBaseUrl(server.Url())}, options...)
+
+    apiClient, err := client.New(Token, options...)
+    if err != nil {
+        panic(err)
+    }
+
+    return apiClient
+}
+
+func (server *Server) Close() {
+    server.httpServer.Close()
+}
+
+// Call is a recorded api call.
+type Call struct {
+    Method string
+    Params map[string]string
+    Files  map[string][]byte
+}
+
+// Calls returns all recorded api calls in order.
+func (server *Server) Calls() []Call {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    return append([]Call{}, server.calls...)
+}
+
+// CallsTo returns the recorded calls of the api method, e.g. "sendMessage".
+func (server *Server) CallsTo(method string) []Call {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    calls := []Call{}
+    for _, call := range server.calls {
+        if call.Method == method {
+            calls = append(calls, call)
+        }
+    }
+
+    return calls
+}
+
+func (server *Server) ResetCalls() {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    server.calls = nil
+}
+
+type failure struct {
+    method   string
+    response *client.ApiResponse
+}
+
+// FailNext makes the next call of the method fail with the error. An empty method matches any method.
+func (server *Server) FailNext(method string, errorCode int64, description string, parameters *client.ResponseParameters) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    server.failures = append(server.failures, &failure{
+        method:   method,
+        response: errorResponse(errorCode, description, parameters),
+    })
+}
+
+// FloodWait makes the next call of the method fail with 429 and retry_after.
+func (server *Server) FloodWait(method string, retryAfter int64) {
+    server.FailNext(method, 429, fmt.Sprintf("Too Many Requests: retry after %d", retryAfter), &client.ResponseParameters{
+        RetryAfter: client.OptionalInt(retryAfter),
+    })
+}
+
+// BlockBot simulates the user blocking the bot: sending to the chat fails with 403.
+func (server *Server) BlockBot(chatId int64) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    server.blocked[chatId] = true
+}
+
+// MigrateChat upgrades the group to the supergroup. Calls to the old chat id fail with migrate_to_chat_id.
+func (server *Server) MigrateChat(oldChatId int64, newChatId int64) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    server.migrations[oldChatId] = newChatId
+
+    chat := &client.Chat{
+        Id:   newChatId,
+        Type: "supergroup",
+    }
+
+    oldChat, ok := server.chats[oldChatId]
+    if ok {
+        chat.Title = oldChat.Title
+        delete(server.chats, oldChatId)
+    }
+
+    server.chats[newChatId] = chat
+}
+
+func (server *Server) serveHTTP(res http.ResponseWriter, req *http.Request) {
+    path := strings.TrimPrefix(req.URL.Path, "/")
+
+    if strings.HasPrefix(path, "file/bot"+Token+"/") {
+        server.serveFile(res, strings.TrimPrefix(path, "file/bot"+Token+"/"))
+        return
+    }
+
+    parts := strings.SplitN(path, "/", 2)
+    if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+        writeResponse(res, errorResponse(404, "Not Found", nil))
+        return
+    }
+
+    if parts[0] != "bot"+Token {
+        writeResponse(res, errorResponse(401, "Unauthorized", nil))
+        return
+    }
+
+    method := parts[1]
+
+    params, err := parseParams(req)
+    if err != nil {
+        writeResponse(res, errorResponse(400, "Bad Request: "+err.Error(), nil))
+        return
+    }
+
+    writeResponse(res, server.call(req, method, params))
+}
+
+func (server *Server) call(req *http.Request, method string, params *params) *client.ApiResponse {
+    server.mu.Lock()
+
+    server.calls = append(server.calls, Call{
+        Method: method,
+        Params: params.values,
+        Files:  params.fileData(),
+    })
+
+    for i, failure := range server.failures {
+        if failure.method == "" || strings.EqualFold(failure.method, method) {
+            server.failures = append(server.failures[:i], server.failures[i+1:]...)
+            server.mu.Unlock()
+            return failure.response
+        }
+    }
+
+    server.mu.Unlock()
+
+    // getUpdates waits for updates, so it locks on its own
+    if strings.EqualFold(method, "getUpdates") {
+        return server.getUpdates(req, params)
+    }
+
+    handler, ok := server.handlers[strings.ToLower(method)]
+    if !ok {
+        return errorResponse(404, "Not Found: method not found", nil)
+    }
+
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    result, apiErr := handler(server, params)
+    if apiErr != nil {
+        return apiErr
+    }
+
+    return resultResponse(result)
+}
+
+func (server *Server) serveFile(res http.ResponseWriter, filePath string) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    for _, file := range server.files {
+        if file.path == filePath {
+            res.Write(file.data)
+            return
+        }
+    }
+
+    res.WriteHeader(http.StatusNotFound)
+}
+
+func (server *Server) nextId() int64 {
+    server.lastId++
+
+    return server.lastId
+}
+
+func resultResponse(result interface{}) *client.ApiResponse {
+    data, _ := json.Marshal(result)
+
+    return &client.ApiResponse{
+        Ok:     true,
+        Result: data,
+    }
+}
+
+func errorResponse(errorCode int64, description string, parameters *client.ResponseParameters) *client.ApiResponse {
+    return &client.ApiResponse{
+        Ok:          false,
+        ErrorCode:   client.OptionalInt(errorCode),
+        Description: client.OptionalString(description),
+        Parameters:  parameters,
+    }
+}
+
+func writeResponse(res http.ResponseWriter, resp *client.ApiResponse) {
+    res.Header().Set("Content-Type", "application/json")
+
+    if !resp.Ok {
+        res.WriteHeader(int(*resp.ErrorCode))
+    }
+
+    json.NewEncoder(res).Encode(resp)
+}
+
+type uploadedFile struct {
+    name string
+    data []byte
+}
+
+type params struct {
+    values map[string]string
+    files  map[string]*uploadedFile
+}
+
+func parseParams(req *http.Request) (*params, error) {
+    params := &params{
+        values: map[string]string{},
+        files:  map[string]*uploadedFile{},
+    }
+
+    contentType := req.Header.Get("Content-Type")
+
+    switch {
+    case strings.HasPrefix(contentType, "multipart/form-data"):
+        err := req.ParseMultipartForm(32 << 20)
+        if err != nil {
+            return nil, err
+        }
+
+        for key, values := range req.MultipartForm.Value {
+            params.values[key] = values[0]
+        }
+
+        for key, fileHeaders := range req.MultipartForm.File {
+            file, err := fileHeaders[0].Open()
+            if err != nil {
+                return nil, err
+            }
+
+            data, err := ioutil.ReadAll(file)
+            file.Close()
+            if err != nil {
+                return nil, err
+            }
+
+            params.files[key] = &uploadedFile{
+                name: fileHeaders[0].Filename,
+                data: data,
+            }
+        }
+
+    case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+        err := req.ParseForm()
+        if err != nil {
+            return nil, err
+        }
+
+        for key, values := range req.PostForm {
+            params.values[key] = values[0]
+        }
+
+    default:
+        if req.Body == nil {
+            break
+        }
+
+        data, err := ioutil.ReadAll(req.Body)
+        if err != nil {
+            return nil, err
+        }
+
+        if len(data) == 0 {
+            break
+        }
+
+        var rawValues map[string]json.RawMessage
+
+        err = json.Unmarshal(data, &rawValues)
+        if err != nil {
+            return nil, err
+        }
+
+        for key, rawValue := range rawValues {
+            var stringValue string
+            if json.Unmarshal(rawValue, &stringValue) == nil {
+                params.values[key] = stringValue
+            } else {
+                params.values[key] = string(rawValue)
+            }
+        }
+    }
+
+    return params, nil
+}
+
+func (params *params) fileData() map[string][]byte {
+    files := map[string][]byte{}
+    for key, file := range params.files {
+        files[key] = file.data
+    }
+
+    return files
+}
+
+func (params *params) has(key string) bool {
+    _, ok := params.values[key]
+    if !ok {
+        _, ok = params.files[key]
+    }
+
+    return ok
+}
+
+func (params *params) string(key string) string {
+    return params.values[key]
+}
+
+func (params *params) optionalString(key string) *string {
+    value, ok := params.values[key]
+    if !ok {
+        return nil
+    }
+
+    return &value
+}
+
+func (params *params) int(key string) int64 {
+    value, _ := strconv.ParseInt(params.values[key], 10, 64)
+
+    return value
+}
+
+func (params *params) float(key string) float64 {
+    value, _ := strconv.ParseFloat(params.values[key], 64)
+
+    return value
+}
+
+func (params *params) bool(key string) bool {
+    value, _ := strconv.ParseBool(params.values[key])
+
+    return value
+}
+
+func (params *params) json(key string, v interface{}) error {
+    return json.Unmarshal([]byte(params.values[key]), v)
+}
@@ -0,0 +1,196 @@
+package grabottest_test
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestGetUpdatesOffsetAndTimeout(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    apiClient := server.Client()
+    ctx := context.Background()
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+    server.AddMessage(chat, user, "first")
+    second := server.AddMessage(chat, user, "second")
+
+    updates, err := apiClient.GetUpdatesCtx(ctx, &client.GetUpdatesRequest{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(updates) != 2 || *updates[1].Message.Text != "second" {
+        t.Fatalf("2 updates expected: %+v", updates)
+    }
+
+    // the offset confirms the updates, the call waits for a new one up to the timeout
+    go func() {
+        time.Sleep(50 * time.Millisecond)
+        server.AddMessage(chat, user, "third")
+    }()
+
+    updates, err = apiClient.GetUpdatesCtx(ctx, &client.GetUpdatesRequest{
+        Offset:  client.OptionalInt(second.UpdateId + 1),
+        Timeout: client.OptionalInt(5),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(updates) != 1 || *updates[0].Message.Text != "third" {
+        t.Fatalf("the third update expected: %+v", updates)
+    }
+
+    start := time.Now()
+
+    updates, err = apiClient.GetUpdatesCtx(ctx, &client.GetUpdatesRequest{
+        Offset:  client.OptionalInt(updates[0].UpdateId + 1),
+        Timeout: client.OptionalInt(1),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(updates) != 0 || time.Since(start) < time.Second {
+        t.Fatalf("no updates expected after the timeout: %+v in %s", updates, time.Since(start))
+    }
+
+    if len(server.PendingUpdates()) != 0 {
+        t.Fatalf("confirmed updates are pending: %+v", server.PendingUpdates())
+    }
+}
+
+func TestWebhookDelivery(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    received := make(chan *http.Request, 10)
+
+    webhookServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+        received <- req
+    }))
+    defer webhookServer.Close()
+
+    apiClient := server.Client()
+    ctx := context.Background()
+
+    _, err := apiClient.SetWebhookCtx(ctx, &client.SetWebhookRequest{
+        Url:            webhookServer.URL,
+        SecretToken:    client.OptionalString("secret"),
+        AllowedUpdates: &[]client.UpdateType{client.UpdateTypeMessage},
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    user := grabottest.NewUser(1, "user")
+    server.AddInlineQuery(user, "filtered out")
+    server.AddMessage(grabottest.NewPrivateChat(user), user, "hello")
+
+    select {
+    case req := <-received:
+        if req.Header.Get("X-Telegram-Bot-Api-Secret-Token") != "secret" {
+            t.Fatal("no secret token in the webhook request")
+        }
+
+    case <-time.After(10 * time.Second):
+        t.Fatal("the update isn't delivered")
+    }
+
+    if len(received) != 0 {
+        t.Fatal("the update of not allowed type is delivered")
+    }
+
+    if len(server.PendingUpdates()) != 0 {
+        t.Fatalf("delivered updates are pending: %+v", server.PendingUpdates())
+    }
+
+    _, err = apiClient.GetUpdatesCtx(ctx, &client.GetUpdatesRequest{})
+
+    var apiErr *client.ApiError
+    if !errors.As(err, &apiErr) || apiErr.ErrorCode != 409 {
+        t.Fatalf("%v, conflict expected while the webhook is set", err)
+    }
+
+    if len(server.CallsTo("setWebhook")) != 1 || server.CallsTo("setWebhook")[0].Params["url"] != webhookServer.URL {
+        t.Fatalf("wrong setWebhook calls: %+v", server.CallsTo("setWebhook"))
+    }
+}
+
+func TestSimulatedErrors(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    apiClient := server.Client()
+    ctx := context.Background()
+
+    server.FailNext("getMe", 500, "Internal Server Error", nil)
+
+    _, err := apiClient.GetMeCtx(ctx)
+
+    var apiErr *client.ApiError
+    if !errors.As(err, &apiErr) || apiErr.ErrorCode != 500 {
+        t.Fatalf("%v, 500 expected", err)
+    }
+
+    _, err = apiClient.GetMeCtx(ctx)
+    if err != nil {
+        t.Fatalf("only the next call should fail: %s", err)
+    }
+
+    server.FloodWait("getMe", 3)
+
+    _, err = apiClient.GetMeCtx(ctx)
+
+    var floodErr *client.TooManyRequestsError
+    if !errors.As(err, &floodErr) || floodErr.RetryAfter != 3*time.Second {
+        t.Fatalf("%v, 429 with retry after 3 seconds expected", err)
+    }
+
+    group := grabottest.NewGroupChat(-100, "group")
+    server.AddChat(group)
+    server.MigrateChat(group.Id, -1000000000100)
+
+    _, err = apiClient.SendMessageCtx(ctx, &client.SendMessageRequest{
+        ChatId: client.IntChatId(group.Id),
+        Text:   "hello",
+    })
+
+    var migratedErr *client.MigratedError
+    if !errors.As(err, &migratedErr) || migratedErr.MigrateToChatId != -1000000000100 {
+        t.Fatalf("%v, migrated error expected", err)
+    }
+
+    _, err = apiClient.SendMessageCtx(ctx, &client.SendMessageRequest{
+        ChatId: client.IntChatId(-1000000000100),
+        Text:   "hello",
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(server.Messages(-1000000000100)) != 1 {
+        t.Fatalf("the message isn't sent to the supergroup: %+v", server.Messages(-1000000000100))
+    }
+
+    user := grabottest.NewUser(1, "user")
+    server.AddChat(grabottest.NewPrivateChat(user))
+    server.BlockBot(user.Id)
+
+    _, err = apiClient.SendMessageCtx(ctx, &client.SendMessageRequest{
+        ChatId: client.IntChatId(user.Id),
+        Text:   "hello",
+    })
+    if !errors.Is(err, client.ErrBotBlocked) {
+        t.Fatalf("%v, blocked bot error expected", err)
+    }
+}
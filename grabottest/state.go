@@ -0,0 +1,273 @@
+package grabottest
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+type file struct {
+    id   string
+    path string
+    name string
+    data []byte
+}
+
+// CallbackAnswer is the bot's answer to a callback query.
+type CallbackAnswer struct {
+    Answered  bool
+    Text      *string
+    ShowAlert bool
+    Url       *string
+}
+
+type webhook struct {
+    url            string
+    hasCertificate bool
+    maxConnections int64
+    allowedUpdates []string
+    secretToken    string
+    lastErrorDate  *int64
+    lastError      *string
+}
+
+// AddChat registers the chat, so the bot can send messages to it. Chats of added updates are registered automatically.
+func (server *Server) AddChat(chat client.Chat) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    server.addChat(chat)
+}
+
+func (server *Server) addChat(chat client.Chat) *client.Chat {
+    existingChat, ok := server.chats[chat.Id]
+    if ok {
+        return existingChat
+    }
+
+    server.chats[chat.Id] = &chat
+
+    return &chat
+}
+
+// Chat returns the current state of the chat.
+func (server *Server) Chat(chatId int64) (client.Chat, bool) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    chat, ok := server.chats[chatId]
+    if !ok {
+        return client.Chat{}, false
+    }
+
+    return *chat, true
+}
+
+// Messages returns the messages of the chat ordered by id. Deleted messages are skipped.
+func (server *Server) Messages(chatId int64) []client.Message {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    messages := []client.Message{}
+    for messageId := int64(1); messageId <= server.lastMessageIds[chatId]; messageId++ {
+        message, ok := server.messages[chatId][messageId]
+        if ok {
+            messages = append(messages, *message)
+        }
+    }
+
+    return messages
+}
+
+// CallbackAnswer returns the answer to the callback query, if the query is known.
+func (server *Server) CallbackAnswer(callbackQueryId string) (CallbackAnswer, bool) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    answer, ok := server.callbackQueries[callbackQueryId]
+    if !ok {
+        return CallbackAnswer{}, false
+    }
+
+    return *answer, true
+}
+
+// AddFile stores the file and returns its file_id.
+func (server *Server) AddFile(name string, data []byte) string {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    return server.addFile(name, data).id
+}
+
+func (server *Server) addFile(name string, data []byte) *file {
+    id := server.nextId()
+
+    file := &file{
+        id:   fmt.Sprintf("file%d", id),
+        path: fmt.Sprintf("files/file_%d_%s", id, name),
+        name: name,
+        data: data,
+    }
+
+    server.files[file.id] = file
+
+    return file
+}
+
+func (server *Server) chatByParam(value string) (*client.Chat, *client.ApiResponse) {
+    if value == "" {
+        return nil, errorResponse(400, "Bad Request: chat_id is empty", nil)
+    }
+
+    if strings.HasPrefix(value, "@") {
+        for _, chat := range server.chats {
+            if chat.Username != nil && strings.EqualFold("@"+*chat.Username, value) {
+                return chat, nil
+            }
+        }
+
+        return nil, errorResponse(400, "Bad Request: chat not found", nil)
+    }
+
+    chatId, err := strconv.ParseInt(value, 10, 64)
+    if err != nil {
+        return nil, errorResponse(400, "Bad Request: chat not found", nil)
+    }
+
+    newChatId, ok := server.migrations[chatId]
+    if ok {
+        return nil, errorResponse(400, "Bad Request: group chat was upgraded to a supergroup chat", &client.ResponseParameters{
+            MigrateToChatId: client.OptionalInt(newChatId),
+        })
+    }
+
+    chat, ok := server.chats[chatId]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: chat not found", nil)
+    }
+
+    return chat, nil
+}
+
+// targetChat resolves the chat the bot writes to.
+func (server *Server) targetChat(params *params) (*client.Chat, *client.ApiResponse) {
+    chat, apiErr := server.chatByParam(params.string("chat_id"))
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    if server.blocked[chat.Id] {
+        return nil, errorResponse(403, "Forbidden: bot was blocked by the user", nil)
+    }
+
+    return chat, nil
+}
+
+func (server *Server) addMessage(message *client.Message) *client.Message {
+    chatId := message.Chat.Id
+
+    if message.MessageId == 0 {
+        message.MessageId = server.lastMessageIds[chatId] + 1
+    }
+
+    if message.MessageId > server.lastMessageIds[chatId] {
+        server.lastMessageIds[chatId] = message.MessageId
+    }
+
+    if message.Date == 0 {
+        message.Date = time.Now().Unix()
+    }
+
+    if server.messages[chatId] == nil {
+        server.messages[chatId] = make(map[int64]*client.Message)
+    }
+
+    server.messages[chatId][message.MessageId] = message
+
+    return message
+}
+
+func (server *Server) newBotMessage(chat *client.Chat, params *params) *client.Message {
+    message := &client.Message{
+        From: &server.Bot,
+        Chat: *chat,
+    }
+
+    if chat.Type == "channel" {
+        message.From = nil
+    }
+
+    if params.has("reply_to_message_id") {
+        replyToMessage, ok := server.messages[chat.Id][params.int("reply_to_message_id")]
+        if ok {
+            reply := *replyToMessage
+            reply.ReplyToMessage = nil
+            message.ReplyToMessage = &reply
+        }
+    }
+
+    return message
+}
+
+// targetMessage resolves the message to edit: chat_id + message_id or inline_message_id.
+func (server *Server) targetMessage(params *params) (*client.Message, *client.ApiResponse) {
+    if params.has("inline_message_id") {
+        return nil, nil
+    }
+
+    chat, apiErr := server.targetChat(params)
+    if apiErr != nil {
+        return nil, apiErr
+    }
+
+    message, ok := server.messages[chat.Id][params.int("message_id")]
+    if !ok {
+        return nil, errorResponse(400, "Bad Request: message to edit not found", nil)
+    }
+
+    return message, nil
+}
+
+// inputFile resolves a file param: an upload, a known file_id or an url.
+func (server *Server) inputFile(params *params, key string) (*file, *client.ApiResponse) {
+    uploadedFile, ok := params.files[key]
+    if ok {
+        return server.addFile(uploadedFile.name, uploadedFile.data), nil
+    }
+
+    value := params.string(key)
+    if value == "" {
+        return nil, errorResponse(400, fmt.Sprintf("Bad Request: there is no %s in the request", key), nil)
+    }
+
+    existingFile, ok := server.files[value]
+    if ok {
+        return existingFile, nil
+    }
+
+    if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+        return server.addFile(value[strings.LastIndex(value, "/")+1:], nil), nil
+    }
+
+    return nil, errorResponse(400, "Bad Request: wrong file identifier/HTTP URL specified", nil)
+}
+
+func (server *Server) member(chatId int64, user client.User) *client.ChatMember {
+    if server.members[chatId] == nil {
+        server.members[chatId] = make(map[int64]*client.ChatMember)
+    }
+
+    member, ok := server.members[chatId][user.Id]
+    if !ok {
+        member = &client.ChatMember{
+            User:   user,
+            Status: "member",
+        }
+        server.members[chatId][user.Id] = member
+    }
+
+    return member
+}
@@ -0,0 +1,367 @@
+package grabottest
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "regexp"
+    "time"
+    "unicode/utf16"
+    "github.com/zelenin/grabot/client"
+)
+
+// AddUpdate queues the update for getUpdates or, if a webhook is set, delivers it to the webhook.
+// UpdateId is assigned if it's zero. Chats and messages of the update are added to the model.
+func (server *Server) AddUpdate(update *client.Update) *client.Update {
+    server.mu.Lock()
+
+    if update.UpdateId == 0 {
+        update.UpdateId = server.lastUpdateId + 1
+    }
+
+    if update.UpdateId > server.lastUpdateId {
+        server.lastUpdateId = update.UpdateId
+    }
+
+    server.registerUpdate(update)
+
+    server.updates = append(server.updates, update)
+
+    close(server.newUpdates)
+    server.newUpdates = make(chan struct{})
+
+    webhook := server.webhook
+
+    server.mu.Unlock()
+
+    if webhook != nil {
+        server.deliver(webhook, update)
+    }
+
+    return update
+}
+
+func (server *Server) registerUpdate(update *client.Update) {
+    for _, message := range []*client.Message{update.Message, update.ChannelPost} {
+        if message != nil {
+            server.addChat(message.Chat)
+            server.addMessage(message)
+            if message.From != nil {
+                server.member(message.Chat.Id, *message.From)
+            }
+        }
+    }
+
+    for _, message := range []*client.Message{update.EditedMessage, update.EditedChannelPost} {
+        if message != nil {
+            server.addChat(message.Chat)
+            server.addMessage(message)
+        }
+    }
+
+    if update.CallbackQuery != nil {
+        server.callbackQueries[update.CallbackQuery.Id] = &CallbackAnswer{}
+    }
+}
+
+// AddMessage adds an update with a text message from the user. Bot commands, mentions and hashtags get entities.
+func (server *Server) AddMessage(chat client.Chat, from client.User, text string) *client.Update {
+    return server.AddUpdate(&client.Update{
+        Message: &client.Message{
+            From:     &from,
+            Chat:     chat,
+            Text:     client.OptionalString(text),
+            Entities: parseEntities(text),
+        },
+    })
+}
+
+// AddCallbackQuery adds an update with the user pressing an inline button with the data under the message.
+func (server *Server) AddCallbackQuery(from client.User, message *client.Message, data string) *client.Update {
+    server.mu.Lock()
+    id := fmt.Sprintf("%d", server.nextId())
+    server.mu.Unlock()
+
+    callbackQuery := &client.CallbackQuery{
+        Id:           id,
+        From:         from,
+        Message:      message,
+        ChatInstance: id,
+        Data:         client.OptionalString(data),
+    }
+
+    return server.AddUpdate(&client.Update{
+        CallbackQuery: callbackQuery,
+    })
+}
+
+// AddInlineQuery adds an update with the inline query from the user.
+func (server *Server) AddInlineQuery(from client.User, query string) *client.Update {
+    server.mu.Lock()
+    id := fmt.Sprintf("%d", server.nextId())
+    server.mu.Unlock()
+
+    return server.AddUpdate(&client.Update{
+        InlineQuery: &client.InlineQuery{
+            Id:    id,
+            From:  from,
+            Query: query,
+        },
+    })
+}
+
+// PendingUpdates returns the updates not yet confirmed by getUpdates.
+func (server *Server) PendingUpdates() []*client.Update {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    return append([]*client.Update{}, server.updates...)
+}
+
+func (server *Server) getUpdates(req *http.Request, params *params) *client.ApiResponse {
+    server.mu.Lock()
+
+    if server.webhook != nil {
+        server.mu.Unlock()
+        return errorResponse(409, "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first", nil)
+    }
+
+    if params.has("offset") {
+        server.confirmUpdates(params.int("offset"))
+    }
+
+    limit := 100
+    if params.has("limit") && params.int("limit") > 0 && params.int("limit") < 100 {
+        limit = int(params.int("limit"))
+    }
+
+    timeout := time.Duration(params.int("timeout")) * time.Second
+
+    var allowedUpdates []string
+    if params.has("allowed_updates") {
+        params.json("allowed_updates", &allowedUpdates)
+    }
+
+    timer := time.NewTimer(timeout)
+    defer timer.Stop()
+
+    for {
+        updates := filterUpdates(server.updates, allowedUpdates, limit)
+        if len(updates) > 0 || timeout == 0 {
+            server.mu.Unlock()
+            return resultResponse(updates)
+        }
+
+        newUpdates := server.newUpdates
+        server.mu.Unlock()
+
+        select {
+        case <-newUpdates:
+            server.mu.Lock()
+
+        case <-timer.C:
+            return resultResponse([]*client.Update{})
+
+        case <-req.Context().Done():
+            return resultResponse([]*client.Update{})
+        }
+    }
+}
+
+func (server *Server) confirmUpdates(offset int64) {
+    if offset < 0 {
+        if int(-offset) < len(server.updates) {
+            server.updates = server.updates[len(server.updates)+int(offset):]
+        }
+        return
+    }
+
+    updates := []*client.Update{}
+    for _, update := range server.updates {
+        if update.UpdateId >= offset {
+            updates = append(updates, update)
+        }
+    }
+
+    server.updates = updates
+}
+
+func filterUpdates(updates []*client.Update, allowedUpdates []string, limit int) []*client.Update {
+    filtered := []*client.Update{}
+
+    for _, update := range updates {
+        if len(filtered) == limit {
+            break
+        }
+
+        if isAllowedUpdate(update, allowedUpdates) {
+            filtered = append(filtered, update)
+        }
+    }
+
+    return filtered
+}
+
+func isAllowedUpdate(update *client.Update, allowedUpdates []string) bool {
+    if len(allowedUpdates) == 0 {
+        return true
+    }
+
+    updateType := client.UpdateTypeOf(update)
+
+    for _, allowedUpdate := range allowedUpdates {
+        if allowedUpdate == updateType.String() {
+            return true
+        }
+    }
+
+    return false
+}
+
+// deliver posts the update to the webhook the way Telegram does. Delivered updates are removed from the queue.
+func (server *Server) deliver(webhook *webhook, update *client.Update) {
+    if !isAllowedUpdate(update, webhook.allowedUpdates) {
+        server.removeUpdate(update)
+        return
+    }
+
+    req, _ := NewWebhookRequest(webhook.url, update)
+
+    if webhook.secretToken != "" {
+        req.Header.Set("X-Telegram-Bot-Api-Secret-Token", webhook.secretToken)
+    }
+
+    resp, err := http.DefaultClient.Do(req)
+    if err == nil {
+        resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            err = fmt.Errorf("Wrong response from the webhook: %s", resp.Status)
+        }
+    }
+
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    if err != nil {
+        webhook.lastErrorDate = client.OptionalInt(time.Now().Unix())
+        webhook.lastError = client.OptionalString(err.Error())
+        return
+    }
+
+    server.removeUpdateLocked(update)
+}
+
+func (server *Server) removeUpdate(update *client.Update) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+
+    server.removeUpdateLocked(update)
+}
+
+func (server *Server) removeUpdateLocked(update *client.Update) {
+    for i, pendingUpdate := range server.updates {
+        if pendingUpdate == update {
+            server.updates = append(server.updates[:i], server.updates[i+1:]...)
+            return
+        }
+    }
+}
+
+// NewWebhookRequest builds the request Telegram sends to a webhook, e.g. to pass it to updates.WebhookHandler directly.
+func NewWebhookRequest(url string, update *client.Update) (*http.Request, error) {
+    data, err := json.Marshal(update)
+    if err != nil {
+        return nil, err
+    }
+
+    req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+
+    req.Header.Set("Content-Type", "application/json")
+
+    return req, nil
+}
+
+var entityRegex = regexp.MustCompile(`(?:^|\s)((/[a-zA-Z0-9_]+(?:@\w+)?)|(@\w{5,})|(#\w+))`)
+
+func parseEntities(text string) *[]client.MessageEntity {
+    entities := []client.MessageEntity{}
+
+    for _, match := range entityRegex.FindAllStringSubmatchIndex(text, -1) {
+        entity := client.MessageEntity{
+            Offset: utf16Len(text[:match[2]]),
+            Length: utf16Len(text[match[2]:match[3]]),
+        }
+
+        switch {
+        case match[4] >= 0:
+            entity.Type = client.MessageEntityBotCommand
+        case match[6] >= 0:
+            entity.Type = client.MessageEntityMention
+        default:
+            entity.Type = client.MessageEntityHashtag
+        }
+
+        entities = append(entities, entity)
+    }
+
+    if len(entities) == 0 {
+        return nil
+    }
+
+    return &entities
+}
+
+func utf16Len(s string) int64 {
+    return int64(len(utf16.Encode([]rune(s))))
+}
+
+// NewUser returns a user to send updates from.
+func NewUser(id int64, firstName string) client.User {
+    return client.User{
+        Id:        id,
+        FirstName: firstName,
+    }
+}
+
+// NewPrivateChat returns the private chat with the user.
+func NewPrivateChat(user client.User) client.Chat {
+    return client.Chat{
+        Id:        user.Id,
+        Type:      "private",
+        FirstName: client.OptionalString(user.FirstName),
+        LastName:  user.LastName,
+        Username:  user.Username,
+    }
+}
+
+// NewGroupChat returns a basic group, the id should be negative.
+func NewGroupChat(id int64, title string) client.Chat {
+    return client.Chat{
+        Id:    id,
+        Type:  "group",
+        Title: client.OptionalString(title),
+    }
+}
+
+// NewSupergroupChat returns a supergroup, the id should be in the -100xxxxxxxxxx range.
+func NewSupergroupChat(id int64, title string) client.Chat {
+    return client.Chat{
+        Id:    id,
+        Type:  "supergroup",
+        Title: client.OptionalString(title),
+    }
+}
+
+// NewChannelChat returns a channel with the username, the id should be in the -100xxxxxxxxxx range.
+func NewChannelChat(id int64, title string, username string) client.Chat {
+    return client.Chat{
+        Id:       id,
+        Type:     "channel",
+        Title:    client.OptionalString(title),
+        Username: client.OptionalString(username),
+    }
+}
@@ -0,0 +1,335 @@
+package updates
+
+import (
+    "bufio"
+    "container/list"
+    "context"
+    "fmt"
+    "os"
+    "sync"
+    "sync/atomic"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+// SeenStore remembers the ids of handled updates.
+type SeenStore interface {
+    // Contains reports whether the update id was seen.
+    Contains(updateId int64) (bool, error)
+    // Seen marks the update id as seen and reports whether it was seen before.
+    Seen(updateId int64) (bool, error)
+}
+
+// NewMemorySeenStore remembers up to size ids, each for ttl. The oldest ids are forgotten first.
+// 0 means no limit, the store grows without bound if neither size nor ttl is set.
+func NewMemorySeenStore(size int, ttl time.Duration) SeenStore {
+    return newMemorySeenStore(size, ttl)
+}
+
+func newMemorySeenStore(size int, ttl time.Duration) *memorySeenStore {
+    return &memorySeenStore{
+        size:     size,
+        ttl:      ttl,
+        elements: make(map[int64]*list.Element),
+        lru:      list.New(),
+    }
+}
+
+type seenEntry struct {
+    updateId int64
+    seenAt   time.Time
+}
+
+type memorySeenStore struct {
+    size     int
+    ttl      time.Duration
+    elements map[int64]*list.Element
+    lru      *list.List
+    mu       sync.Mutex
+}
+
+func (store *memorySeenStore) Seen(updateId int64) (bool, error) {
+    return store.seenAt(updateId, time.Now()), nil
+}
+
+func (store *memorySeenStore) Contains(updateId int64) (bool, error) {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    element, ok := store.elements[updateId]
+
+    return ok && (store.ttl <= 0 || time.Since(element.Value.(*seenEntry).seenAt) < store.ttl), nil
+}
+
+func (store *memorySeenStore) seenAt(updateId int64, now time.Time) bool {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    // the list is ordered by seenAt, so expired ids are at the back
+    store.expire(now)
+
+    _, ok := store.elements[updateId]
+    if ok {
+        return true
+    }
+
+    store.elements[updateId] = store.lru.PushFront(&seenEntry{
+        updateId: updateId,
+        seenAt:   now,
+    })
+
+    for store.size > 0 && store.lru.Len() > store.size {
+        store.remove(store.lru.Back())
+    }
+
+    return false
+}
+
+func (store *memorySeenStore) len() int {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    return store.lru.Len()
+}
+
+func (store *memorySeenStore) expire(now time.Time) {
+    if store.ttl <= 0 {
+        return
+    }
+
+    for oldest := store.lru.Back(); oldest != nil && now.Sub(oldest.Value.(*seenEntry).seenAt) >= store.ttl; oldest = store.lru.Back() {
+        store.remove(oldest)
+    }
+}
+
+func (store *memorySeenStore) remove(element *list.Element) {
+    store.lru.Remove(element)
+    delete(store.elements, element.Value.(*seenEntry).updateId)
+}
+
+// NewFileSeenStore is a memory store persisted to an append-only file, so it survives restarts.
+// The file is compacted when it has twice as many lines as ids remembered, i.e. not forgotten by size or ttl.
+func NewFileSeenStore(path string, size int, ttl time.Duration) (SeenStore, error) {
+    store := &fileSeenStore{
+        memory: newMemorySeenStore(size, ttl),
+        path:   path,
+    }
+
+    err := store.load()
+    if err != nil {
+        return nil, err
+    }
+
+    err = store.compact()
+    if err != nil {
+        return nil, err
+    }
+
+    return store, nil
+}
+
+// minSeenFileLines keeps a small file from being compacted on every write.
+const minSeenFileLines = 100
+
+type fileSeenStore struct {
+    memory *memorySeenStore
+    path   string
+    file   *os.File
+    lines  int
+    mu     sync.Mutex
+}
+
+func (store *fileSeenStore) Contains(updateId int64) (bool, error) {
+    return store.memory.Contains(updateId)
+}
+
+func (store *fileSeenStore) Seen(updateId int64) (bool, error) {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    now := time.Now()
+
+    if store.memory.seenAt(updateId, now) {
+        return true, nil
+    }
+
+    _, err := fmt.Fprintf(store.file, "%d %d\n", updateId, now.UnixNano())
+    if err != nil {
+        return false, err
+    }
+
+    store.lines++
+
+    if store.lines > minSeenFileLines && store.lines > 2*store.memory.len() {
+        err = store.compact()
+    }
+
+    return false, err
+}
+
+func (store *fileSeenStore) load() error {
+    file, err := os.Open(store.path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        var updateId, seenAt int64
+
+        _, err := fmt.Sscanf(scanner.Text(), "%d %d", &updateId, &seenAt)
+        if err != nil {
+            continue
+        }
+
+        if store.memory.ttl > 0 && time.Since(time.Unix(0, seenAt)) >= store.memory.ttl {
+            continue
+        }
+
+        store.memory.seenAt(updateId, time.Unix(0, seenAt))
+    }
+
+    return scanner.Err()
+}
+
+// compact rewrites the file with the ids in memory.
+func (store *fileSeenStore) compact() error {
+    if store.file != nil {
+        store.file.Close()
+    }
+
+    tmpPath := store.path + ".tmp"
+
+    file, err := os.Create(tmpPath)
+    if err != nil {
+        return err
+    }
+
+    writer := bufio.NewWriter(file)
+    lines := 0
+
+    for element := store.memory.lru.Back(); element != nil; element = element.Prev() {
+        entry := element.Value.(*seenEntry)
+        fmt.Fprintf(writer, "%d %d\n", entry.updateId, entry.seenAt.UnixNano())
+        lines++
+    }
+
+    err = writer.Flush()
+    if err == nil {
+        err = file.Close()
+    } else {
+        file.Close()
+    }
+
+    if err == nil {
+        err = os.Rename(tmpPath, store.path)
+    }
+
+    if err != nil {
+        os.Remove(tmpPath)
+        return err
+    }
+
+    store.file, err = os.OpenFile(store.path, os.O_APPEND|os.O_WRONLY, 0644)
+    store.lines = lines
+
+    return err
+}
+
+// Deduplicator drops updates with already seen ids, e.g. webhook redeliveries or at-least-once replays.
+// An update is marked as seen after it's handled, so an update lost in a crash is handled again when it's redelivered.
+// Until then its duplicates are dropped as in flight.
+type Deduplicator struct {
+    store    SeenStore
+    handled  int64
+    dropped  int64
+    errors   int64
+    mu       sync.Mutex
+    inFlight map[int64]bool
+}
+
+type DeduplicatorStats struct {
+    Handled int64
+    Dropped int64
+    // Store errors. Updates are handled if the store fails.
+    Errors int64
+}
+
+func NewDeduplicator(store SeenStore) *Deduplicator {
+    return &Deduplicator{
+        store:    store,
+        inFlight: make(map[int64]bool),
+    }
+}
+
+// Begin reports whether the update is new: not seen and not in flight. A new update is in flight until Done.
+func (deduplicator *Deduplicator) Begin(update *client.Update) bool {
+    deduplicator.mu.Lock()
+    defer deduplicator.mu.Unlock()
+
+    seen, err := deduplicator.store.Contains(update.UpdateId)
+    if err != nil {
+        atomic.AddInt64(&deduplicator.errors, 1)
+    }
+
+    if seen || deduplicator.inFlight[update.UpdateId] {
+        atomic.AddInt64(&deduplicator.dropped, 1)
+        return false
+    }
+
+    deduplicator.inFlight[update.UpdateId] = true
+
+    atomic.AddInt64(&deduplicator.handled, 1)
+
+    return true
+}
+
+// Done ends the handling of the update started by Begin. If it's handled, it's marked as seen,
+// otherwise (e.g. the handler panicked) it will be handled again.
+func (deduplicator *Deduplicator) Done(update *client.Update, handled bool) {
+    if handled {
+        _, err := deduplicator.store.Seen(update.UpdateId)
+        if err != nil {
+            atomic.AddInt64(&deduplicator.errors, 1)
+        }
+    }
+
+    deduplicator.mu.Lock()
+    delete(deduplicator.inFlight, update.UpdateId)
+    deduplicator.mu.Unlock()
+}
+
+// Handle passes new updates to the handler, see Begin and Done.
+func (deduplicator *Deduplicator) Handle(ctx context.Context, update *client.Update, updateHandler UpdateHandler) {
+    if !deduplicator.Begin(update) {
+        return
+    }
+
+    handled := false
+    defer func() {
+        deduplicator.Done(update, handled)
+    }()
+
+    updateHandler(ctx, update)
+
+    handled = true
+}
+
+// Wrap returns the handler skipping duplicates.
+func (deduplicator *Deduplicator) Wrap(updateHandler UpdateHandler) UpdateHandler {
+    return func(ctx context.Context, update *client.Update) {
+        deduplicator.Handle(ctx, update, updateHandler)
+    }
+}
+
+func (deduplicator *Deduplicator) Stats() DeduplicatorStats {
+    return DeduplicatorStats{
+        Handled: atomic.LoadInt64(&deduplicator.handled),
+        Dropped: atomic.LoadInt64(&deduplicator.dropped),
+        Errors:  atomic.LoadInt64(&deduplicator.errors),
+    }
+}
@@ -0,0 +1,126 @@
+package updates
+
+import (
+    "bytes"
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+func TestDeduplicatorCrashedUpdateIsHandledAgain(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "seen")
+
+    store, err := NewFileSeenStore(path, 100, time.Hour)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    update := &client.Update{UpdateId: 1}
+
+    func() {
+        defer func() {
+            recover()
+        }()
+
+        NewDeduplicator(store).Wrap(func(ctx context.Context, update *client.Update) {
+            panic("crash")
+        })(context.Background(), update)
+    }()
+
+    // restart
+    store, err = NewFileSeenStore(path, 100, time.Hour)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    deduplicator := NewDeduplicator(store)
+    handled := 0
+    handler := deduplicator.Wrap(func(ctx context.Context, update *client.Update) {
+        handled++
+    })
+
+    handler(context.Background(), update)
+    handler(context.Background(), update)
+
+    if handled != 1 {
+        t.Fatalf("the redelivered update is handled %d times", handled)
+    }
+
+    stats := deduplicator.Stats()
+    if stats.Handled != 1 || stats.Dropped != 1 || stats.Errors != 0 {
+        t.Fatalf("wrong stats: %+v", stats)
+    }
+}
+
+func TestDeduplicatorInFlight(t *testing.T) {
+    deduplicator := NewDeduplicator(NewMemorySeenStore(100, time.Hour))
+    update := &client.Update{UpdateId: 1}
+
+    handled := 0
+    handler := deduplicator.Wrap(func(ctx context.Context, update *client.Update) {
+        handled++
+    })
+
+    deduplicator.Wrap(func(ctx context.Context, update *client.Update) {
+        handler(ctx, update)
+    })(context.Background(), update)
+
+    if handled != 0 {
+        t.Fatal("the duplicate of the update in flight is handled")
+    }
+}
+
+func TestMemorySeenStoreExpiry(t *testing.T) {
+    store := newMemorySeenStore(0, time.Minute)
+
+    now := time.Now()
+
+    for i := int64(1); i <= 1000; i++ {
+        store.seenAt(i, now)
+    }
+
+    if !store.seenAt(1, now.Add(time.Second)) {
+        t.Fatal("the id should be seen within the ttl")
+    }
+
+    if store.seenAt(1, now.Add(time.Minute)) {
+        t.Fatal("the id should be forgotten after the ttl")
+    }
+
+    if store.len() != 1 {
+        t.Fatalf("expired ids are held: %d", store.len())
+    }
+}
+
+func TestFileSeenStoreCompactsExpired(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "seen")
+
+    store, err := NewFileSeenStore(path, 0, 10*time.Millisecond)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for i := int64(1); i <= 1000; i++ {
+        _, err = store.Seen(i)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if i%100 == 0 {
+            time.Sleep(20 * time.Millisecond)
+        }
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    lines := bytes.Count(data, []byte("\n"))
+    if lines > 2*minSeenFileLines {
+        t.Fatalf("the file isn't compacted: %d lines", lines)
+    }
+}
@@ -0,0 +1,148 @@
+package updates
+
+import (
+    "context"
+    "sync"
+    "github.com/zelenin/grabot/client"
+)
+
+// Dispatcher handles updates of the same chat (or user, if there is no chat) one by one in order,
+// while updates of different chats are handled in parallel.
+//
+// Its Handle method is an UpdateHandler, so it's passed to NewWebhookHandler, or fed from a long poller with Run.
+type Dispatcher struct {
+    updateHandler UpdateHandler
+    handlers      *handlerGroup
+    semaphore     chan struct{}
+    mu            sync.Mutex
+    queues        map[int64][]*client.Update
+    closed        bool
+    afterHandle   func(update *client.Update)
+}
+
+type DispatcherOption func(*Dispatcher)
+
+// WithAfterHandle calls f after the handler of each update returns, e.g. to acknowledge the update.
+func WithAfterHandle(f func(update *client.Update)) DispatcherOption {
+    return func(dispatcher *Dispatcher) {
+        dispatcher.afterHandle = f
+    }
+}
+
+// NewDispatcher handles up to concurrency updates at the same time, 0 means no limit. A chat takes a slot
+// for each update, so chats with long queues take turns with other chats.
+// Handlers get a context canceled when Shutdown gives up draining.
+func NewDispatcher(updateHandler UpdateHandler, concurrency int, options ...DispatcherOption) *Dispatcher {
+    dispatcher := &Dispatcher{
+        updateHandler: updateHandler,
+        handlers:      newHandlerGroup(),
+        queues:        make(map[int64][]*client.Update),
+    }
+
+    if concurrency > 0 {
+        dispatcher.semaphore = make(chan struct{}, concurrency)
+    }
+
+    for _, option := range options {
+        option(dispatcher)
+    }
+
+    return dispatcher
+}
+
+// Handle queues the update. It doesn't wait for the update to be handled.
+func (dispatcher *Dispatcher) Handle(ctx context.Context, update *client.Update) {
+    key := UpdateKey(update)
+
+    dispatcher.mu.Lock()
+    defer dispatcher.mu.Unlock()
+
+    if dispatcher.closed {
+        return
+    }
+
+    queue, running := dispatcher.queues[key]
+    dispatcher.queues[key] = append(queue, update)
+
+    if !running {
+        dispatcher.handlers.wg.Add(1)
+        go dispatcher.run(key)
+    }
+}
+
+// Run dispatches updates from the channel, e.g. from a LongPoller, until ctx is done or the channel is closed.
+func (dispatcher *Dispatcher) Run(ctx context.Context, updates <-chan *client.Update) {
+    for {
+        select {
+        case update, ok := <-updates:
+            if !ok {
+                return
+            }
+            dispatcher.Handle(ctx, update)
+
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// run handles the queue of the key until it's empty.
+func (dispatcher *Dispatcher) run(key int64) {
+    defer dispatcher.handlers.wg.Done()
+
+    for {
+        dispatcher.mu.Lock()
+        queue := dispatcher.queues[key]
+        if len(queue) == 0 {
+            delete(dispatcher.queues, key)
+            dispatcher.mu.Unlock()
+            return
+        }
+        update := queue[0]
+        dispatcher.queues[key] = queue[1:]
+        dispatcher.mu.Unlock()
+
+        dispatcher.handle(update)
+    }
+}
+
+// handle takes a slot for a single update, so a busy chat waits behind other chats for the next one.
+func (dispatcher *Dispatcher) handle(update *client.Update) {
+    if dispatcher.semaphore != nil {
+        dispatcher.semaphore <- struct{}{}
+        defer func() {
+            <-dispatcher.semaphore
+        }()
+    }
+
+    dispatcher.updateHandler(dispatcher.handlers.ctx, update)
+
+    if dispatcher.afterHandle != nil {
+        dispatcher.afterHandle(update)
+    }
+}
+
+// Shutdown makes Handle drop new updates and waits until the queues of all chats are empty.
+// If ctx is done first, ctx.Err() is returned and the queued updates are handled with a canceled context.
+func (dispatcher *Dispatcher) Shutdown(ctx context.Context) error {
+    dispatcher.mu.Lock()
+    dispatcher.closed = true
+    dispatcher.mu.Unlock()
+
+    return dispatcher.handlers.drain(ctx)
+}
+
+// UpdateKey returns the id updates are ordered by: the chat id if the update has a chat, the sender id otherwise.
+func UpdateKey(update *client.Update) int64 {
+    chat := client.UpdateChat(update)
+    if chat != nil {
+        return chat.Id
+    }
+
+    user := client.UpdateUser(update)
+    if user != nil {
+        return user.Id
+    }
+
+    return 0
+}
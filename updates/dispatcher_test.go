@@ -0,0 +1,123 @@
+package updates
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestDispatcherOrderAndShutdown(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+
+    mu := sync.Mutex{}
+    handled := []int64{}
+
+    dispatcher := NewDispatcher(func(ctx context.Context, update *client.Update) {
+        time.Sleep(time.Millisecond)
+
+        mu.Lock()
+        handled = append(handled, update.UpdateId)
+        mu.Unlock()
+    }, 10)
+
+    expected := []int64{}
+    for i := 0; i < 10; i++ {
+        update := server.AddMessage(chat, user, "hello")
+        expected = append(expected, update.UpdateId)
+        dispatcher.Handle(context.Background(), update)
+    }
+
+    err := dispatcher.Shutdown(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // updates after shutdown are dropped
+    dispatcher.Handle(context.Background(), server.AddMessage(chat, user, "late"))
+
+    mu.Lock()
+    defer mu.Unlock()
+
+    if len(handled) != len(expected) {
+        t.Fatalf("handled: %v, expected: %v", handled, expected)
+    }
+
+    for i := range expected {
+        if handled[i] != expected[i] {
+            t.Fatalf("handled: %v, expected: %v", handled, expected)
+        }
+    }
+}
+
+func TestDispatcherShutdownTimeout(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+
+    canceled := make(chan struct{})
+
+    dispatcher := NewDispatcher(func(ctx context.Context, update *client.Update) {
+        <-ctx.Done()
+        close(canceled)
+    }, 0)
+
+    dispatcher.Handle(context.Background(), server.AddMessage(grabottest.NewPrivateChat(user), user, "hello"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+
+    err := dispatcher.Shutdown(ctx)
+    if err != context.DeadlineExceeded {
+        t.Fatalf("%v, context.DeadlineExceeded expected", err)
+    }
+
+    select {
+    case <-canceled:
+    case <-time.After(10 * time.Second):
+        t.Fatal("the context of the handler isn't canceled")
+    }
+}
+
+func TestDispatcherBusyChatTakesTurns(t *testing.T) {
+    mu := sync.Mutex{}
+    handled := []int64{}
+    started := make(chan struct{}, 10)
+
+    dispatcher := NewDispatcher(func(ctx context.Context, update *client.Update) {
+        mu.Lock()
+        handled = append(handled, update.UpdateId)
+        mu.Unlock()
+
+        started <- struct{}{}
+        time.Sleep(20 * time.Millisecond)
+    }, 1)
+
+    busyChat := client.Chat{Id: 1}
+    for i := int64(1); i <= 3; i++ {
+        dispatcher.Handle(context.Background(), &client.Update{UpdateId: i, Message: &client.Message{Chat: busyChat}})
+    }
+
+    <-started
+
+    dispatcher.Handle(context.Background(), &client.Update{UpdateId: 4, Message: &client.Message{Chat: client.Chat{Id: 2}}})
+
+    err := dispatcher.Shutdown(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+
+    if len(handled) != 4 || handled[1] != 4 {
+        t.Fatalf("the other chat should be handled after the first update of the busy one: %v", handled)
+    }
+}
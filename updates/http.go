@@ -1,19 +1,99 @@
 package updates
 
 import (
+    "context"
+    "net"
     "net/http"
     "io/ioutil"
     "encoding/json"
+    "crypto/subtle"
+    "strings"
     "github.com/zelenin/grabot/client"
 )
 
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
 type WebhookHandler struct {
-    updateHandler UpdateHandler
+    updateHandler   UpdateHandler
+    path            string
+    secretToken     string
+    allowedNetworks []*net.IPNet
+    trustedProxies  []*net.IPNet
+    pool            *WorkerPool
+    poolConfig      *workerPoolConfig
+    rawHook         func(data json.RawMessage)
+}
+
+type workerPoolConfig struct {
+    concurrency    int
+    queueSize      int
+    overflowPolicy OverflowPolicy
+}
+
+type WebhookOption func(*WebhookHandler)
+
+// WithSecretToken rejects requests without the X-Telegram-Bot-Api-Secret-Token header equal to the token.
+// Pass the same token to SetWebhookRequest.SecretToken.
+func WithSecretToken(secretToken string) WebhookOption {
+    return func(handler *WebhookHandler) {
+        handler.secretToken = secretToken
+    }
+}
+
+// WithPath rejects requests to other paths, e.g. when the handler is mounted on a prefix. See NewSecretPath.
+func WithPath(path string) WebhookOption {
+    return func(handler *WebhookHandler) {
+        handler.path = path
+    }
+}
+
+// WithAllowedNetworks rejects requests from other networks, e.g. TelegramNetworks.
+func WithAllowedNetworks(networks ...*net.IPNet) WebhookOption {
+    return func(handler *WebhookHandler) {
+        handler.allowedNetworks = networks
+    }
+}
+
+// WithTrustedProxies makes the handler take the client ip from X-Forwarded-For if the request comes from these networks.
+func WithTrustedProxies(networks ...*net.IPNet) WebhookOption {
+    return func(handler *WebhookHandler) {
+        handler.trustedProxies = networks
+    }
+}
+
+// WithWebhookRawHook passes the request body of each valid update to the hook before the update is handled.
+// See Recorder.RawHook.
+func WithWebhookRawHook(hook func(data json.RawMessage)) WebhookOption {
+    return func(handler *WebhookHandler) {
+        handler.rawHook = hook
+    }
+}
+
+// WithAsyncDispatch makes the handler ack webhook requests immediately and handle updates in a WorkerPool.
+// SetWebhookResponse is not available then. Call Shutdown to drain the pool.
+func WithAsyncDispatch(concurrency int, queueSize int, overflowPolicy OverflowPolicy) WebhookOption {
+    return func(handler *WebhookHandler) {
+        handler.poolConfig = &workerPoolConfig{
+            concurrency:    concurrency,
+            queueSize:      queueSize,
+            overflowPolicy: overflowPolicy,
+        }
+    }
 }
 
 func (handler WebhookHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
     defer req.Body.Close()
 
+    if handler.path != "" && req.URL.Path != handler.path {
+        res.WriteHeader(http.StatusNotFound)
+        return
+    }
+
+    if !handler.isTrustedRequest(req) {
+        res.WriteHeader(http.StatusForbidden)
+        return
+    }
+
     if !isValidWebhookRequest(req) {
         res.WriteHeader(http.StatusBadRequest)
         return
@@ -33,7 +113,90 @@ func (handler WebhookHandler) ServeHTTP(res http.ResponseWriter, req *http.Reque
         return
     }
 
-    handler.updateHandler(req.Context(), &update)
+    if handler.rawHook != nil {
+        handler.rawHook(data)
+    }
+
+    if handler.pool != nil {
+        err = handler.pool.Submit(req.Context(), &update)
+        if err != nil {
+            res.WriteHeader(http.StatusServiceUnavailable)
+        }
+        return
+    }
+
+    ctx, response := withWebhookResponse(req.Context())
+
+    handler.updateHandler(ctx, &update)
+
+    data = response.get()
+    if data != nil {
+        res.Header().Set("Content-Type", "application/json")
+        res.Write(data)
+    }
+}
+
+func (handler WebhookHandler) isTrustedRequest(req *http.Request) bool {
+    if handler.secretToken != "" {
+        secretToken := req.Header.Get(secretTokenHeader)
+        if subtle.ConstantTimeCompare([]byte(secretToken), []byte(handler.secretToken)) != 1 {
+            return false
+        }
+    }
+
+    if len(handler.allowedNetworks) > 0 {
+        ip := handler.clientIp(req)
+        if ip == nil || !containsIp(handler.allowedNetworks, ip) {
+            return false
+        }
+    }
+
+    return true
+}
+
+// clientIp walks X-Forwarded-For from the right while addresses belong to trusted proxies.
+func (handler WebhookHandler) clientIp(req *http.Request) net.IP {
+    host, _, err := net.SplitHostPort(req.RemoteAddr)
+    if err != nil {
+        host = req.RemoteAddr
+    }
+
+    ip := net.ParseIP(host)
+    if ip == nil || !containsIp(handler.trustedProxies, ip) {
+        return ip
+    }
+
+    forwardedFor := req.Header.Values("X-Forwarded-For")
+    if len(forwardedFor) == 0 {
+        return ip
+    }
+
+    forwardedIps := strings.Split(strings.Join(forwardedFor, ","), ",")
+
+    for i := len(forwardedIps) - 1; i >= 0; i-- {
+        forwardedIp := net.ParseIP(strings.TrimSpace(forwardedIps[i]))
+        if forwardedIp == nil {
+            return nil
+        }
+
+        ip = forwardedIp
+
+        if !containsIp(handler.trustedProxies, ip) {
+            return ip
+        }
+    }
+
+    return ip
+}
+
+func containsIp(networks []*net.IPNet, ip net.IP) bool {
+    for _, network := range networks {
+        if network.Contains(ip) {
+            return true
+        }
+    }
+
+    return false
 }
 
 func isValidWebhookRequest(req *http.Request) bool {
@@ -52,8 +215,27 @@ func isValidWebhookRequest(req *http.Request) bool {
     return true
 }
 
-func NewWebhookHandler(updateHandler UpdateHandler) *WebhookHandler {
-    return &WebhookHandler{
+func NewWebhookHandler(updateHandler UpdateHandler, options ...WebhookOption) *WebhookHandler {
+    handler := &WebhookHandler{
         updateHandler: updateHandler,
     }
+
+    for _, option := range options {
+        option(handler)
+    }
+
+    if handler.poolConfig != nil {
+        handler.pool = NewWorkerPool(updateHandler, handler.poolConfig.concurrency, handler.poolConfig.queueSize, handler.poolConfig.overflowPolicy)
+    }
+
+    return handler
+}
+
+// Shutdown drains the worker pool of async dispatch, see WorkerPool.Shutdown. Stop the http server first.
+func (handler *WebhookHandler) Shutdown(ctx context.Context) error {
+    if handler.pool == nil {
+        return nil
+    }
+
+    return handler.pool.Shutdown(ctx)
 }
@@ -2,6 +2,9 @@ package updates
 
 import (
     "time"
+    "errors"
+    "encoding/json"
+    "sync"
     "github.com/zelenin/grabot/client"
     "context"
 )
@@ -10,15 +13,94 @@ type LongPoller interface {
     LongPoll(ctx context.Context, initReq *client.GetUpdatesRequest, interval time.Duration) (chan *client.Update, chan error)
 }
 
+// AckLongPoller is implemented by long pollers in at-least-once mode: see WithAtLeastOnce.
+type AckLongPoller interface {
+    LongPoller
+    Ack(update *client.Update) error
+}
+
 type BasicLongPoller struct {
-    client *client.Client
+    client      *client.Client
+    timeout     time.Duration
+    minBackoff  time.Duration
+    maxBackoff  time.Duration
+    offsetStore OffsetStore
+    atLeastOnce bool
+    rawHook     func(data json.RawMessage)
+
+    mu            sync.Mutex
+    committed     int64
+    lastDelivered int64
+    inFlight      []int64
+    acked         map[int64]bool
+    ackSignal     chan struct{}
+}
+
+type LongPollerOption func(*BasicLongPoller)
+
+// WithLongPollTimeout switches the poller to server-side long polling: getUpdates is issued back-to-back
+// and the server holds each call up to timeout until updates arrive. The interval of LongPoll is ignored then.
+func WithLongPollTimeout(timeout time.Duration) LongPollerOption {
+    return func(longPoller *BasicLongPoller) {
+        longPoller.timeout = timeout
+    }
+}
+
+// WithErrorBackoff sets the pause after a failed getUpdates in long polling mode.
+// It doubles for each consecutive error from min up to max. Defaults to 1 second and 1 minute.
+func WithErrorBackoff(min time.Duration, max time.Duration) LongPollerOption {
+    return func(longPoller *BasicLongPoller) {
+        longPoller.minBackoff = min
+        longPoller.maxBackoff = max
+    }
+}
+
+// WithOffsetStore makes the poller start from the saved offset and save it as updates are received (or acknowledged).
+func WithOffsetStore(offsetStore OffsetStore) LongPollerOption {
+    return func(longPoller *BasicLongPoller) {
+        longPoller.offsetStore = offsetStore
+    }
+}
+
+// WithAtLeastOnce makes the poller commit the offset only for updates acknowledged with Ack, so updates
+// not handled before a restart are received again. Updates are acknowledged in any order,
+// the offset moves past the longest acknowledged sequence.
+func WithAtLeastOnce() LongPollerOption {
+    return func(longPoller *BasicLongPoller) {
+        longPoller.atLeastOnce = true
+    }
+}
+
+// WithLongPollRawHook passes the json of each update to the hook as it's received from the api, before it's delivered.
+// Updates received again in at-least-once mode are skipped. See Recorder.RawHook.
+func WithLongPollRawHook(hook func(data json.RawMessage)) LongPollerOption {
+    return func(longPoller *BasicLongPoller) {
+        longPoller.rawHook = hook
+    }
 }
 
 func (longPoller *BasicLongPoller) LongPoll(ctx context.Context, initReq *client.GetUpdatesRequest, interval time.Duration) (chan *client.Update, chan error) {
     updates := make(chan *client.Update, 1000)
     errs := make(chan error, 1000)
 
-    go longPoller.longPoll(ctx, initReq, interval, updates, errs)
+    if longPoller.offsetStore != nil {
+        offset, err := longPoller.offsetStore.Load()
+        if err != nil {
+            errs <- err
+        } else if offset > 0 {
+            initReq.Offset = client.OptionalInt(offset)
+        }
+    }
+
+    if initReq.Offset != nil {
+        longPoller.committed = *initReq.Offset
+    }
+
+    if longPoller.timeout > 0 {
+        go longPoller.serverLongPoll(ctx, initReq, updates, errs)
+    } else {
+        go longPoller.longPoll(ctx, initReq, interval, updates, errs)
+    }
 
     return updates, errs
 }
@@ -29,17 +111,17 @@ func (longPoller *BasicLongPoller) longPoll(ctx context.Context, initReq *client
     for {
         select {
         case <-ticker.C:
-            updates, err := longPoller.client.GetUpdates(initReq)
+            state := longPoller.ackState()
+
+            updates, raws, err := longPoller.fetchUpdates(ctx, initReq)
             if err != nil {
                 errs <- err
                 continue
             }
 
-            for _, update := range updates {
-                updatesChan <- update
-                if *initReq.Offset <= update.UpdateId {
-                    initReq.Offset = client.OptionalInt(update.UpdateId + 1)
-                }
+            if !longPoller.deliver(ctx, initReq, updates, raws, updatesChan, errs, state) {
+                errs <- ctx.Err()
+                return
             }
 
         case <-ctx.Done():
@@ -49,8 +131,287 @@ func (longPoller *BasicLongPoller) longPoll(ctx context.Context, initReq *client
     }
 }
 
-func NewLongPoller(client *client.Client) LongPoller {
-    return &BasicLongPoller{
-        client: client,
+func (longPoller *BasicLongPoller) serverLongPoll(ctx context.Context, initReq *client.GetUpdatesRequest, updatesChan chan *client.Update, errs chan error) {
+    defer func() {
+        select {
+        case errs <- ctx.Err():
+        default:
+        }
+    }()
+
+    timeout := int64(longPoller.timeout / time.Second)
+    if timeout < 1 {
+        timeout = 1
+    }
+
+    initReq.Timeout = client.OptionalInt(timeout)
+
+    backoff := longPoller.minBackoff
+
+    for ctx.Err() == nil {
+        state := longPoller.ackState()
+
+        updates, raws, err := longPoller.getUpdates(ctx, initReq)
+        if err != nil {
+            if ctx.Err() != nil {
+                return
+            }
+
+            // errors are dropped if nobody reads them, the poller must not stall
+            select {
+            case errs <- err:
+            default:
+            }
+
+            delay := backoff
+
+            var floodErr *client.TooManyRequestsError
+            if errors.As(err, &floodErr) && floodErr.RetryAfter > delay {
+                delay = floodErr.RetryAfter
+            }
+
+            if !sleep(ctx, delay) {
+                return
+            }
+
+            backoff *= 2
+            if backoff > longPoller.maxBackoff {
+                backoff = longPoller.maxBackoff
+            }
+
+            continue
+        }
+
+        backoff = longPoller.minBackoff
+
+        if !longPoller.deliver(ctx, initReq, updates, raws, updatesChan, errs, state) {
+            return
+        }
+    }
+}
+
+// ackState is the committed offset and the signal of its next change, taken before getUpdates.
+type ackState struct {
+    committed int64
+    ackSignal chan struct{}
+}
+
+func (longPoller *BasicLongPoller) ackState() ackState {
+    longPoller.mu.Lock()
+    defer longPoller.mu.Unlock()
+
+    return ackState{
+        committed: longPoller.committed,
+        ackSignal: longPoller.ackSignal,
+    }
+}
+
+// deliver sends the updates to the consumer and moves the offset. It returns false if ctx is done.
+// raws are the json of the updates if there is the raw hook.
+func (longPoller *BasicLongPoller) deliver(ctx context.Context, initReq *client.GetUpdatesRequest, updates []*client.Update, raws []json.RawMessage, updatesChan chan *client.Update, errs chan error, state ackState) bool {
+    if longPoller.atLeastOnce {
+        return longPoller.deliverAtLeastOnce(ctx, initReq, updates, raws, updatesChan, state)
+    }
+
+    for i, update := range updates {
+        longPoller.callRawHook(raws, i)
+
+        select {
+        case updatesChan <- update:
+
+        case <-ctx.Done():
+            return false
+        }
+
+        if initReq.Offset == nil || *initReq.Offset <= update.UpdateId {
+            initReq.Offset = client.OptionalInt(update.UpdateId + 1)
+        }
+    }
+
+    if longPoller.offsetStore != nil && len(updates) > 0 {
+        err := longPoller.offsetStore.Save(*initReq.Offset)
+        if err != nil {
+            select {
+            case errs <- err:
+            default:
+            }
+        }
+    }
+
+    return true
+}
+
+// deliverAtLeastOnce keeps the offset at the first unacknowledged update, so getUpdates returns
+// the updates in flight again: they are skipped. If there are no new updates, it waits for an ack
+// instead of polling in a busy loop. The state is taken before getUpdates, so acks during the call aren't missed.
+func (longPoller *BasicLongPoller) deliverAtLeastOnce(ctx context.Context, initReq *client.GetUpdatesRequest, updates []*client.Update, raws []json.RawMessage, updatesChan chan *client.Update, state ackState) bool {
+    delivered := 0
+
+    for i, update := range updates {
+        if !longPoller.track(update) {
+            continue
+        }
+
+        longPoller.callRawHook(raws, i)
+
+        select {
+        case updatesChan <- update:
+            delivered++
+
+        case <-ctx.Done():
+            return false
+        }
+    }
+
+    longPoller.mu.Lock()
+    acked := longPoller.committed != state.committed
+    longPoller.mu.Unlock()
+
+    if delivered == 0 && len(updates) > 0 && !acked {
+        select {
+        case <-state.ackSignal:
+
+        case <-ctx.Done():
+            return false
+        }
+    }
+
+    longPoller.mu.Lock()
+    if longPoller.committed > 0 {
+        initReq.Offset = client.OptionalInt(longPoller.committed)
+    }
+    longPoller.mu.Unlock()
+
+    return true
+}
+
+func (longPoller *BasicLongPoller) track(update *client.Update) bool {
+    longPoller.mu.Lock()
+    defer longPoller.mu.Unlock()
+
+    if update.UpdateId < longPoller.committed || update.UpdateId <= longPoller.lastDelivered {
+        return false
+    }
+
+    longPoller.lastDelivered = update.UpdateId
+    longPoller.inFlight = append(longPoller.inFlight, update.UpdateId)
+
+    return true
+}
+
+// Ack confirms the update is handled. It's a no-op unless the poller is in at-least-once mode.
+func (longPoller *BasicLongPoller) Ack(update *client.Update) error {
+    if !longPoller.atLeastOnce {
+        return nil
+    }
+
+    longPoller.mu.Lock()
+    defer longPoller.mu.Unlock()
+
+    // updates not in flight are committed already or unknown
+    if !longPoller.isInFlight(update.UpdateId) {
+        return nil
+    }
+
+    longPoller.acked[update.UpdateId] = true
+
+    committed := longPoller.committed
+    for len(longPoller.inFlight) > 0 && longPoller.acked[longPoller.inFlight[0]] {
+        committed = longPoller.inFlight[0] + 1
+        delete(longPoller.acked, longPoller.inFlight[0])
+        longPoller.inFlight = longPoller.inFlight[1:]
+    }
+
+    changed := committed != longPoller.committed
+    longPoller.committed = committed
+
+    if changed {
+        close(longPoller.ackSignal)
+        longPoller.ackSignal = make(chan struct{})
+    }
+
+    if changed && longPoller.offsetStore != nil {
+        return longPoller.offsetStore.Save(committed)
+    }
+
+    return nil
+}
+
+func (longPoller *BasicLongPoller) isInFlight(updateId int64) bool {
+    for _, inFlightId := range longPoller.inFlight {
+        if inFlightId == updateId {
+            return true
+        }
+    }
+
+    return false
+}
+
+// getUpdates bounds the call a bit longer than the long polling window, so a lost connection can't hang the poller.
+func (longPoller *BasicLongPoller) getUpdates(ctx context.Context, req *client.GetUpdatesRequest) ([]*client.Update, []json.RawMessage, error) {
+    ctx, cancel := context.WithTimeout(ctx, longPoller.timeout+10*time.Second)
+    defer cancel()
+
+    return longPoller.fetchUpdates(ctx, req)
+}
+
+// fetchUpdates decodes the updates itself if there is the raw hook, so their json is kept for it.
+func (longPoller *BasicLongPoller) fetchUpdates(ctx context.Context, req *client.GetUpdatesRequest) ([]*client.Update, []json.RawMessage, error) {
+    if longPoller.rawHook == nil {
+        updates, err := longPoller.client.GetUpdatesCtx(ctx, req)
+
+        return updates, nil, err
+    }
+
+    raws, err := longPoller.client.GetUpdatesRawCtx(ctx, req)
+    if err != nil {
+        return nil, nil, err
     }
+
+    updates := make([]*client.Update, len(raws))
+    for i, raw := range raws {
+        updates[i] = &client.Update{}
+
+        err = json.Unmarshal(raw, updates[i])
+        if err != nil {
+            return nil, nil, err
+        }
+    }
+
+    return updates, raws, nil
+}
+
+func (longPoller *BasicLongPoller) callRawHook(raws []json.RawMessage, i int) {
+    if longPoller.rawHook != nil && i < len(raws) {
+        longPoller.rawHook(raws[i])
+    }
+}
+
+func sleep(ctx context.Context, duration time.Duration) bool {
+    timer := time.NewTimer(duration)
+    defer timer.Stop()
+
+    select {
+    case <-timer.C:
+        return true
+
+    case <-ctx.Done():
+        return false
+    }
+}
+
+func NewLongPoller(client *client.Client, options ...LongPollerOption) LongPoller {
+    longPoller := &BasicLongPoller{
+        client:     client,
+        minBackoff: time.Second,
+        maxBackoff: time.Minute,
+        acked:      make(map[int64]bool),
+        ackSignal:  make(chan struct{}),
+    }
+
+    for _, option := range options {
+        option(longPoller)
+    }
+
+    return longPoller
 }
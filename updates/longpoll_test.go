@@ -0,0 +1,87 @@
+package updates
+
+import (
+    "context"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestLongPollAtLeastOnceFastAcks(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    longPoller := NewLongPoller(server.Client(), WithLongPollTimeout(time.Second), WithAtLeastOnce()).(*BasicLongPoller)
+    updatesChan, _ := longPoller.LongPoll(ctx, &client.GetUpdatesRequest{}, 0)
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+
+    for i := 0; i < 50; i++ {
+        server.AddMessage(chat, user, "hello")
+
+        select {
+        case update := <-updatesChan:
+            time.Sleep(time.Duration(i%5) * 50 * time.Microsecond)
+
+            err := longPoller.Ack(update)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+        case <-ctx.Done():
+            t.Fatalf("the poller stalled after %d updates", i)
+        }
+    }
+
+    longPoller.mu.Lock()
+    defer longPoller.mu.Unlock()
+
+    if len(longPoller.acked) != 0 || len(longPoller.inFlight) != 0 {
+        t.Fatalf("acked: %v, in flight: %v", longPoller.acked, longPoller.inFlight)
+    }
+}
+
+func TestLongPollAtLeastOnceRedelivery(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+    first := server.AddMessage(chat, user, "first")
+    second := server.AddMessage(chat, user, "second")
+
+    offsetStore := NewMemoryOffsetStore()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    longPoller := NewLongPoller(server.Client(), WithLongPollTimeout(time.Second), WithOffsetStore(offsetStore), WithAtLeastOnce()).(AckLongPoller)
+    updatesChan, _ := longPoller.LongPoll(ctx, &client.GetUpdatesRequest{}, 0)
+
+    // the second update is received, but not handled
+    longPoller.Ack(<-updatesChan)
+    <-updatesChan
+    // unknown and repeated acks are ignored
+    longPoller.Ack(first)
+    longPoller.Ack(&client.Update{UpdateId: 1000})
+    cancel()
+
+    ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    longPoller = NewLongPoller(server.Client(), WithLongPollTimeout(time.Second), WithOffsetStore(offsetStore), WithAtLeastOnce()).(AckLongPoller)
+    updatesChan, _ = longPoller.LongPoll(ctx, &client.GetUpdatesRequest{}, 0)
+
+    select {
+    case update := <-updatesChan:
+        if update.UpdateId != second.UpdateId {
+            t.Fatalf("update #%d is redelivered, #%d expected", update.UpdateId, second.UpdateId)
+        }
+
+    case <-ctx.Done():
+        t.Fatal("the update isn't redelivered")
+    }
+}
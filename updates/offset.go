@@ -0,0 +1,96 @@
+package updates
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// OffsetStore keeps the getUpdates offset between restarts of the long poller.
+type OffsetStore interface {
+    // Load returns the saved offset or 0 if nothing is saved yet.
+    Load() (int64, error)
+    Save(offset int64) error
+}
+
+func NewMemoryOffsetStore() OffsetStore {
+    return &memoryOffsetStore{}
+}
+
+type memoryOffsetStore struct {
+    offset int64
+    mu     sync.Mutex
+}
+
+func (store *memoryOffsetStore) Load() (int64, error) {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    return store.offset, nil
+}
+
+func (store *memoryOffsetStore) Save(offset int64) error {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    store.offset = offset
+
+    return nil
+}
+
+// NewFileOffsetStore keeps the offset in the file. The file is replaced atomically on each save.
+func NewFileOffsetStore(path string) OffsetStore {
+    return &fileOffsetStore{
+        path: path,
+    }
+}
+
+type fileOffsetStore struct {
+    path string
+    mu   sync.Mutex
+}
+
+func (store *fileOffsetStore) Load() (int64, error) {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    data, err := ioutil.ReadFile(store.path)
+    if os.IsNotExist(err) {
+        return 0, nil
+    }
+    if err != nil {
+        return 0, err
+    }
+
+    return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func (store *fileOffsetStore) Save(offset int64) error {
+    store.mu.Lock()
+    defer store.mu.Unlock()
+
+    tmpFile, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".tmp")
+    if err != nil {
+        return err
+    }
+
+    _, err = tmpFile.WriteString(strconv.FormatInt(offset, 10))
+    if err == nil {
+        err = tmpFile.Sync()
+    }
+
+    closeErr := tmpFile.Close()
+    if err == nil {
+        err = closeErr
+    }
+
+    if err != nil {
+        os.Remove(tmpFile.Name())
+        return err
+    }
+
+    return os.Rename(tmpFile.Name(), store.path)
+}
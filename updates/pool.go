@@ -0,0 +1,157 @@
+package updates
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "github.com/zelenin/grabot/client"
+)
+
+var (
+    ErrQueueFull  = errors.New("update queue is full")
+    ErrPoolClosed = errors.New("worker pool is closed")
+)
+
+// OverflowPolicy decides what happens to an update when the queue of a WorkerPool is full.
+type OverflowPolicy int
+
+const (
+    // OverflowBlock waits for a free slot in the queue.
+    OverflowBlock OverflowPolicy = iota
+    // OverflowDrop drops the update.
+    OverflowDrop
+    // OverflowReject returns ErrQueueFull, a webhook answers 503 then, so Telegram delivers the update later.
+    OverflowReject
+)
+
+// WorkerPool handles updates asynchronously with bounded concurrency and queue.
+type WorkerPool struct {
+    updateHandler  UpdateHandler
+    overflowPolicy OverflowPolicy
+    queue          chan *client.Update
+    handlers       *handlerGroup
+    mu             sync.RWMutex
+    closed         bool
+    dropped        int64
+}
+
+// NewWorkerPool starts concurrency workers. Handlers get a context canceled when Shutdown gives up draining.
+func NewWorkerPool(updateHandler UpdateHandler, concurrency int, queueSize int, overflowPolicy OverflowPolicy) *WorkerPool {
+    if concurrency < 1 {
+        concurrency = 1
+    }
+
+    if queueSize < 0 {
+        queueSize = 0
+    }
+
+    pool := &WorkerPool{
+        updateHandler:  updateHandler,
+        overflowPolicy: overflowPolicy,
+        queue:          make(chan *client.Update, queueSize),
+        handlers:       newHandlerGroup(),
+    }
+
+    pool.handlers.wg.Add(concurrency)
+    for i := 0; i < concurrency; i++ {
+        go pool.work()
+    }
+
+    return pool
+}
+
+func (pool *WorkerPool) work() {
+    defer pool.handlers.wg.Done()
+
+    for update := range pool.queue {
+        pool.updateHandler(pool.handlers.ctx, update)
+    }
+}
+
+// Submit queues the update according to the overflow policy. ctx bounds the wait of OverflowBlock.
+func (pool *WorkerPool) Submit(ctx context.Context, update *client.Update) error {
+    pool.mu.RLock()
+    defer pool.mu.RUnlock()
+
+    if pool.closed {
+        return ErrPoolClosed
+    }
+
+    select {
+    case pool.queue <- update:
+        return nil
+
+    default:
+    }
+
+    switch pool.overflowPolicy {
+    case OverflowDrop:
+        atomic.AddInt64(&pool.dropped, 1)
+        return nil
+
+    case OverflowReject:
+        return ErrQueueFull
+    }
+
+    select {
+    case pool.queue <- update:
+        return nil
+
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+// Dropped returns the number of updates dropped by OverflowDrop.
+func (pool *WorkerPool) Dropped() int64 {
+    return atomic.LoadInt64(&pool.dropped)
+}
+
+// Shutdown makes Submit return ErrPoolClosed and waits until the workers empty the queue.
+// If ctx is done first, ctx.Err() is returned and the workers handle the rest of the queue with a canceled context.
+func (pool *WorkerPool) Shutdown(ctx context.Context) error {
+    pool.mu.Lock()
+    if !pool.closed {
+        pool.closed = true
+        close(pool.queue)
+    }
+    pool.mu.Unlock()
+
+    return pool.handlers.drain(ctx)
+}
+
+// handlerGroup tracks running handlers of a WorkerPool or a Dispatcher and the context they get.
+type handlerGroup struct {
+    ctx    context.Context
+    cancel context.CancelFunc
+    wg     sync.WaitGroup
+}
+
+func newHandlerGroup() *handlerGroup {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    return &handlerGroup{
+        ctx:    ctx,
+        cancel: cancel,
+    }
+}
+
+// drain waits for the handlers until ctx is done, then the context of the handlers is canceled.
+func (handlers *handlerGroup) drain(ctx context.Context) error {
+    defer handlers.cancel()
+
+    done := make(chan struct{})
+    go func() {
+        handlers.wg.Wait()
+        close(done)
+    }()
+
+    select {
+    case <-done:
+        return nil
+
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
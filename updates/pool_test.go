@@ -0,0 +1,125 @@
+package updates
+
+import (
+    "context"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestWebhookAsyncDispatch(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    started := make(chan struct{}, 10)
+    release := make(chan struct{})
+    handled := int64(0)
+
+    handler := NewWebhookHandler(func(ctx context.Context, update *client.Update) {
+        started <- struct{}{}
+        <-release
+        atomic.AddInt64(&handled, 1)
+    }, WithAsyncDispatch(2, 2, OverflowReject))
+
+    webhookServer := httptest.NewServer(handler)
+    defer webhookServer.Close()
+
+    _, err := server.Client().SetWebhookCtx(context.Background(), &client.SetWebhookRequest{
+        Url: webhookServer.URL,
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+
+    // 2 updates are handled, 2 are queued, the rest is rejected and stays pending
+    for i := 0; i < 2; i++ {
+        server.AddMessage(chat, user, "hello")
+        <-started
+    }
+
+    for i := 0; i < 4; i++ {
+        server.AddMessage(chat, user, "hello")
+    }
+
+    if len(server.PendingUpdates()) != 2 {
+        t.Fatalf("2 rejected updates expected, %d pending", len(server.PendingUpdates()))
+    }
+
+    close(release)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    err = handler.Shutdown(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if count := atomic.LoadInt64(&handled); count != 4 {
+        t.Fatalf("4 handled updates expected, %d handled", count)
+    }
+
+    err = handler.pool.Submit(context.Background(), &client.Update{})
+    if err != ErrPoolClosed {
+        t.Fatalf("%v, ErrPoolClosed expected", err)
+    }
+}
+
+func TestWorkerPoolOverflowPolicies(t *testing.T) {
+    release := make(chan struct{})
+    started := make(chan struct{}, 10)
+
+    handler := func(ctx context.Context, update *client.Update) {
+        started <- struct{}{}
+        <-release
+    }
+
+    // a busy worker and a full queue of 1
+    fill := func(pool *WorkerPool) {
+        pool.Submit(context.Background(), &client.Update{UpdateId: 1})
+        <-started
+        pool.Submit(context.Background(), &client.Update{UpdateId: 2})
+    }
+
+    drop := NewWorkerPool(handler, 1, 1, OverflowDrop)
+    fill(drop)
+
+    err := drop.Submit(context.Background(), &client.Update{UpdateId: 3})
+    if err != nil || drop.Dropped() != 1 {
+        t.Fatalf("the update should be dropped: %v, %d dropped", err, drop.Dropped())
+    }
+
+    reject := NewWorkerPool(handler, 1, 1, OverflowReject)
+    fill(reject)
+
+    err = reject.Submit(context.Background(), &client.Update{UpdateId: 3})
+    if err != ErrQueueFull {
+        t.Fatalf("%v, ErrQueueFull expected", err)
+    }
+
+    block := NewWorkerPool(handler, 1, 1, OverflowBlock)
+    fill(block)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+
+    err = block.Submit(ctx, &client.Update{UpdateId: 3})
+    if err != context.DeadlineExceeded {
+        t.Fatalf("%v, the wait should be bounded by ctx", err)
+    }
+
+    close(release)
+
+    for _, pool := range []*WorkerPool{drop, reject, block} {
+        err = pool.Shutdown(context.Background())
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+}
@@ -0,0 +1,220 @@
+package updates
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "io"
+    "os"
+    "sync"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+// Keys of the update json to pass to NewRecorder for redaction.
+const (
+    RedactPhoneNumbers = "phone_number"
+    RedactPassportData = "passport_data"
+)
+
+// RecordedUpdate is a line of the record file.
+type RecordedUpdate struct {
+    Time   time.Time       `json:"time"`
+    Update json.RawMessage `json:"update"`
+}
+
+// Recorder writes updates as newline-delimited json with the time they were received.
+type Recorder struct {
+    writer     io.Writer
+    redactKeys map[string]bool
+    mu         sync.Mutex
+}
+
+// NewRecorder writes to the writer. Values of redactKeys at any depth of the update are replaced with "[redacted]".
+func NewRecorder(writer io.Writer, redactKeys ...string) *Recorder {
+    recorder := &Recorder{
+        writer:     writer,
+        redactKeys: make(map[string]bool),
+    }
+
+    for _, key := range redactKeys {
+        recorder.redactKeys[key] = true
+    }
+
+    return recorder
+}
+
+// NewFileRecorder appends to the file. Close the file when done.
+func NewFileRecorder(path string, redactKeys ...string) (*Recorder, *os.File, error) {
+    file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return NewRecorder(file, redactKeys...), file, nil
+}
+
+// Record records the update encoded from the struct, fields the struct doesn't have are lost.
+// Prefer RecordRaw if the json of the update is at hand.
+func (recorder *Recorder) Record(update *client.Update) error {
+    data, err := json.Marshal(update)
+    if err != nil {
+        return err
+    }
+
+    return recorder.RecordRaw(data)
+}
+
+// RecordRaw records the json of the update as it's received from the api.
+func (recorder *Recorder) RecordRaw(data json.RawMessage) error {
+    var err error
+
+    if len(recorder.redactKeys) > 0 {
+        var value interface{}
+
+        // numbers are kept as they are, ids don't fit float64 precision in general
+        decoder := json.NewDecoder(bytes.NewReader(data))
+        decoder.UseNumber()
+
+        err = decoder.Decode(&value)
+        if err != nil {
+            return err
+        }
+
+        data, err = json.Marshal(recorder.redact(value))
+        if err != nil {
+            return err
+        }
+    }
+
+    line, err := json.Marshal(RecordedUpdate{
+        Time:   time.Now(),
+        Update: data,
+    })
+    if err != nil {
+        return err
+    }
+
+    recorder.mu.Lock()
+    defer recorder.mu.Unlock()
+
+    _, err = recorder.writer.Write(append(line, '\n'))
+
+    return err
+}
+
+func (recorder *Recorder) redact(value interface{}) interface{} {
+    switch value := value.(type) {
+    case map[string]interface{}:
+        for key, nestedValue := range value {
+            if recorder.redactKeys[key] {
+                value[key] = "[redacted]"
+            } else {
+                value[key] = recorder.redact(nestedValue)
+            }
+        }
+
+    case []interface{}:
+        for i, nestedValue := range value {
+            value[i] = recorder.redact(nestedValue)
+        }
+    }
+
+    return value
+}
+
+// RawHook returns the hook for WithWebhookRawHook and WithLongPollRawHook recording the json of updates.
+// Recording errors are ignored.
+func (recorder *Recorder) RawHook() func(data json.RawMessage) {
+    return func(data json.RawMessage) {
+        recorder.RecordRaw(data)
+    }
+}
+
+// Wrap returns the handler recording updates before handling them. Recording errors don't stop handling.
+// Fields the Update struct doesn't have are lost, use RawHook to record updates as they are received.
+func (recorder *Recorder) Wrap(updateHandler UpdateHandler) UpdateHandler {
+    return func(ctx context.Context, update *client.Update) {
+        recorder.Record(update)
+
+        updateHandler(ctx, update)
+    }
+}
+
+// ReplaySource is an UpdateSource feeding recorded updates to the handler.
+type ReplaySource struct {
+    open  func() (io.ReadCloser, error)
+    speed float64
+}
+
+// NewReplaySource replays the record from the reader. speed 1 keeps the original pace, 10 is ten times faster,
+// 0 replays without pauses.
+func NewReplaySource(reader io.Reader, speed float64) *ReplaySource {
+    return &ReplaySource{
+        open: func() (io.ReadCloser, error) {
+            return io.NopCloser(reader), nil
+        },
+        speed: speed,
+    }
+}
+
+func NewFileReplaySource(path string, speed float64) *ReplaySource {
+    return &ReplaySource{
+        open: func() (io.ReadCloser, error) {
+            return os.Open(path)
+        },
+        speed: speed,
+    }
+}
+
+// Run returns when the record is over or ctx is done.
+func (source *ReplaySource) Run(ctx context.Context, updateHandler UpdateHandler) error {
+    reader, err := source.open()
+    if err != nil {
+        return err
+    }
+    defer reader.Close()
+
+    scanner := bufio.NewScanner(reader)
+    scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+    var previousTime time.Time
+
+    for scanner.Scan() {
+        if len(scanner.Bytes()) == 0 {
+            continue
+        }
+
+        var recordedUpdate RecordedUpdate
+
+        err = json.Unmarshal(scanner.Bytes(), &recordedUpdate)
+        if err != nil {
+            return err
+        }
+
+        var update client.Update
+
+        err = json.Unmarshal(recordedUpdate.Update, &update)
+        if err != nil {
+            return err
+        }
+
+        if source.speed > 0 && !previousTime.IsZero() && recordedUpdate.Time.After(previousTime) {
+            pause := time.Duration(float64(recordedUpdate.Time.Sub(previousTime)) / source.speed)
+            if !sleep(ctx, pause) {
+                return nil
+            }
+        }
+
+        previousTime = recordedUpdate.Time
+
+        if ctx.Err() != nil {
+            return nil
+        }
+
+        updateHandler(ctx, &update)
+    }
+
+    return scanner.Err()
+}
@@ -0,0 +1,84 @@
+package updates
+
+import (
+    "bytes"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestRecorderWebhookRawHook(t *testing.T) {
+    buffer := &bytes.Buffer{}
+    recorder := NewRecorder(buffer, RedactPhoneNumbers)
+
+    handled := false
+    handler := NewWebhookHandler(func(ctx context.Context, update *client.Update) {
+        handled = true
+    }, WithWebhookRawHook(recorder.RawHook()))
+
+    body := `{"update_id": 9007199254740993, "message": {"message_id": 1, "date": 0, "chat": {"id": 1, "type": "private"},
+        "contact": {"phone_number": "+123", "first_name": "Bob"}, "future_field": {"x": 1}}}`
+
+    req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+
+    handler.ServeHTTP(httptest.NewRecorder(), req)
+
+    if !handled {
+        t.Fatal("the update isn't handled")
+    }
+
+    line := buffer.String()
+
+    for _, expected := range []string{`"future_field":{"x":1}`, `"phone_number":"[redacted]"`, `"update_id":9007199254740993`} {
+        if !strings.Contains(line, expected) {
+            t.Fatalf("%s expected in %s", expected, line)
+        }
+    }
+
+    if strings.Count(line, "\n") != 1 {
+        t.Fatalf("one line expected: %q", line)
+    }
+}
+
+func TestRecorderLongPollRawHook(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    chat := grabottest.NewPrivateChat(user)
+    first := server.AddMessage(chat, user, "first")
+    second := server.AddMessage(chat, user, "second")
+
+    buffer := &bytes.Buffer{}
+    recorder := NewRecorder(buffer)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    longPoller := NewLongPoller(server.Client(), WithLongPollTimeout(time.Second), WithAtLeastOnce(), WithLongPollRawHook(recorder.RawHook())).(AckLongPoller)
+    updatesChan, _ := longPoller.LongPoll(ctx, &client.GetUpdatesRequest{}, 0)
+
+    // the second update is received again until the first one is acknowledged, it's recorded once
+    <-updatesChan
+    <-updatesChan
+    time.Sleep(100 * time.Millisecond)
+    cancel()
+
+    replayed := []int64{}
+    err := NewReplaySource(buffer, 0).Run(context.Background(), func(ctx context.Context, update *client.Update) {
+        replayed = append(replayed, update.UpdateId)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(replayed) != 2 || replayed[0] != first.UpdateId || replayed[1] != second.UpdateId {
+        t.Fatalf("recorded updates: %v", replayed)
+    }
+}
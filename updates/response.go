@@ -0,0 +1,110 @@
+package updates
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "reflect"
+    "strings"
+    "sync"
+    "unicode"
+)
+
+var (
+    ErrNoWebhookResponse         = errors.New("context is not bound to a webhook response")
+    ErrWebhookResponseAlreadySet = errors.New("webhook response is already set")
+)
+
+type webhookResponseKey struct{}
+
+// webhookResponse is the api call to answer the webhook request with.
+type webhookResponse struct {
+    data []byte
+    mu   sync.Mutex
+}
+
+func withWebhookResponse(ctx context.Context) (context.Context, *webhookResponse) {
+    response := &webhookResponse{}
+
+    return context.WithValue(ctx, webhookResponseKey{}, response), response
+}
+
+// SetWebhookResponse answers the webhook request with the api call, saving a round-trip, e.g.:
+//
+//     updates.SetWebhookResponse(ctx, &client.SendMessageRequest{...})
+//
+// The method is derived from the request type: SendMessageRequest -> sendMessage.
+// Only one call per update is allowed and its result is unknown to the bot.
+// Files can't be uploaded this way, use file ids or urls.
+func SetWebhookResponse(ctx context.Context, req interface{}) error {
+    return SetWebhookResponseMethod(ctx, methodName(req), req)
+}
+
+// SetWebhookResponseMethod is SetWebhookResponse with an explicit method name.
+func SetWebhookResponseMethod(ctx context.Context, method string, req interface{}) error {
+    response, ok := ctx.Value(webhookResponseKey{}).(*webhookResponse)
+    if !ok {
+        return ErrNoWebhookResponse
+    }
+
+    data, err := encodeWebhookResponse(method, req)
+    if err != nil {
+        return err
+    }
+
+    response.mu.Lock()
+    defer response.mu.Unlock()
+
+    if response.data != nil {
+        return ErrWebhookResponseAlreadySet
+    }
+
+    response.data = data
+
+    return nil
+}
+
+func (response *webhookResponse) get() []byte {
+    response.mu.Lock()
+    defer response.mu.Unlock()
+
+    return response.data
+}
+
+func encodeWebhookResponse(method string, req interface{}) ([]byte, error) {
+    params := map[string]json.RawMessage{}
+
+    if req != nil {
+        data, err := json.Marshal(req)
+        if err != nil {
+            return nil, err
+        }
+
+        err = json.Unmarshal(data, &params)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    params["method"], _ = json.Marshal(method)
+
+    return json.Marshal(params)
+}
+
+func methodName(req interface{}) string {
+    reqType := reflect.TypeOf(req)
+    for reqType != nil && reqType.Kind() == reflect.Ptr {
+        reqType = reqType.Elem()
+    }
+
+    if reqType == nil {
+        return ""
+    }
+
+    name := []rune(strings.TrimSuffix(reqType.Name(), "Request"))
+    if len(name) > 0 {
+        name[0] = unicode.ToLower(name[0])
+    }
+
+    return string(name)
+}
@@ -0,0 +1,115 @@
+package updates
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http/httptest"
+    "testing"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestMethodName(t *testing.T) {
+    tests := []struct {
+        req      interface{}
+        expected string
+    }{
+        {&client.SendMessageRequest{}, "sendMessage"},
+        {client.SendMessageRequest{}, "sendMessage"},
+        {&client.AnswerCallbackQueryRequest{}, "answerCallbackQuery"},
+        {nil, ""},
+    }
+
+    for _, test := range tests {
+        actual := methodName(test.req)
+        if actual != test.expected {
+            t.Errorf("methodName(%T) = %q, %q expected", test.req, actual, test.expected)
+        }
+    }
+}
+
+func TestEncodeWebhookResponse(t *testing.T) {
+    data, err := encodeWebhookResponse("sendMessage", &client.SendMessageRequest{
+        ChatId: client.IntChatId(1),
+        Text:   "hello",
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var params map[string]interface{}
+
+    err = json.Unmarshal(data, &params)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if params["method"] != "sendMessage" || params["chat_id"] != float64(1) || params["text"] != "hello" {
+        t.Fatalf("wrong response: %s", data)
+    }
+
+    if _, ok := params["parse_mode"]; ok {
+        t.Fatalf("empty fields are encoded: %s", data)
+    }
+
+    data, err = encodeWebhookResponse("close", nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if string(data) != `{"method":"close"}` {
+        t.Fatalf("wrong response without params: %s", data)
+    }
+}
+
+func TestSetWebhookResponse(t *testing.T) {
+    err := SetWebhookResponse(context.Background(), &client.SendMessageRequest{})
+    if !errors.Is(err, ErrNoWebhookResponse) {
+        t.Fatalf("%v, ErrNoWebhookResponse expected", err)
+    }
+
+    var secondErr error
+
+    handler := NewWebhookHandler(func(ctx context.Context, update *client.Update) {
+        SetWebhookResponse(ctx, &client.SendMessageRequest{
+            ChatId: client.IntChatId(update.Message.Chat.Id),
+            Text:   "pong",
+        })
+        secondErr = SetWebhookResponse(ctx, &client.SendMessageRequest{})
+    })
+
+    req, err := grabottest.NewWebhookRequest("http://localhost/", &client.Update{
+        UpdateId: 1,
+        Message: &client.Message{
+            MessageId: 1,
+            Chat:      client.Chat{Id: 1, Type: "private"},
+            Text:      client.OptionalString("ping"),
+        },
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    res := httptest.NewRecorder()
+    handler.ServeHTTP(res, req)
+
+    if !errors.Is(secondErr, ErrWebhookResponseAlreadySet) {
+        t.Fatalf("%v, ErrWebhookResponseAlreadySet expected", secondErr)
+    }
+
+    if res.Header().Get("Content-Type") != "application/json" {
+        t.Fatalf("wrong content type: %s", res.Header().Get("Content-Type"))
+    }
+
+    var params map[string]interface{}
+
+    err = json.Unmarshal(res.Body.Bytes(), &params)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if params["method"] != "sendMessage" || params["text"] != "pong" {
+        t.Fatalf("wrong response: %s", res.Body.String())
+    }
+}
@@ -0,0 +1,264 @@
+package updates
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "net/url"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+var (
+    ErrWebhookUrlChanged     = errors.New("webhook url changed")
+    ErrWebhookDeliveryFailed = errors.New("webhook delivery failed")
+    ErrWebhookPendingGrowing = errors.New("webhook pending update count is growing")
+)
+
+type WebhookConfig struct {
+    // Public url of the webhook. Its path is served.
+    Url string
+    // Address to listen on, e.g. ":8443".
+    ListenAddr string
+    // TLS certificate and key. If empty, plain http is served, e.g. behind a TLS-terminating proxy.
+    CertFile string
+    KeyFile  string
+    // Upload CertFile as a self-signed certificate.
+    SelfSigned     bool
+    MaxConnections int64
+    AllowedUpdates []client.UpdateType
+    // See WithSecretToken.
+    SecretToken string
+    // Interval of the webhook state checks with getWebhookInfo. Defaults to 1 minute, negative disables the checks.
+    CheckInterval time.Duration
+    // Called on problems found by the checks. A changed url is reported once for each new url.
+    OnProblem func(err error, info *client.WebhookInfo)
+    // Register the webhook again if the checks find another url. Don't use it if several deployments with
+    // different urls run at the same time, e.g. blue/green: they would take the webhook from each other.
+    ReregisterOnChange bool
+    // Remove the webhook on shutdown, e.g. to switch to long polling. Don't use it with rolling deploys.
+    DeleteOnShutdown bool
+    // Time to finish requests in progress on shutdown. Defaults to 10 seconds.
+    ShutdownTimeout time.Duration
+    // Additional options of the WebhookHandler.
+    Options []WebhookOption
+}
+
+// WebhookRunner registers the webhook, serves it and removes it on shutdown.
+type WebhookRunner struct {
+    client *client.Client
+    config WebhookConfig
+}
+
+func NewWebhookRunner(client *client.Client, config WebhookConfig) *WebhookRunner {
+    if config.CheckInterval == 0 {
+        config.CheckInterval = time.Minute
+    }
+
+    if config.ShutdownTimeout <= 0 {
+        config.ShutdownTimeout = 10 * time.Second
+    }
+
+    return &WebhookRunner{
+        client: client,
+        config: config,
+    }
+}
+
+// Run serves updates to the handler until ctx is done.
+func (runner *WebhookRunner) Run(ctx context.Context, updateHandler UpdateHandler) error {
+    webhookUrl, err := url.Parse(runner.config.Url)
+    if err != nil {
+        return err
+    }
+
+    path := webhookUrl.Path
+    if path == "" {
+        path = "/"
+    }
+
+    options := []WebhookOption{WithPath(path)}
+    if runner.config.SecretToken != "" {
+        options = append(options, WithSecretToken(runner.config.SecretToken))
+    }
+    options = append(options, runner.config.Options...)
+
+    handler := NewWebhookHandler(updateHandler, options...)
+
+    // the port is bound before the webhook is set, so a failed listen doesn't leave a dead webhook
+    listener, err := net.Listen("tcp", runner.listenAddr())
+    if err != nil {
+        return err
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    err = runner.setWebhook(ctx)
+    if err != nil {
+        listener.Close()
+        return err
+    }
+
+    srv := &http.Server{
+        Addr:    runner.config.ListenAddr,
+        Handler: handler,
+    }
+
+    serveErrs := make(chan error, 1)
+    go func() {
+        if runner.config.CertFile != "" {
+            serveErrs <- srv.ServeTLS(listener, runner.config.CertFile, runner.config.KeyFile)
+        } else {
+            serveErrs <- srv.Serve(listener)
+        }
+    }()
+
+    if runner.config.CheckInterval > 0 {
+        go runner.check(ctx)
+    }
+
+    select {
+    case err = <-serveErrs:
+
+    case <-ctx.Done():
+    }
+
+    // the checks must not re-register the webhook of the stopped server
+    cancel()
+
+    return runner.shutdown(srv, handler, err)
+}
+
+func (runner *WebhookRunner) listenAddr() string {
+    if runner.config.ListenAddr == "" && runner.config.CertFile != "" {
+        return ":https"
+    }
+
+    if runner.config.ListenAddr == "" {
+        return ":http"
+    }
+
+    return runner.config.ListenAddr
+}
+
+func (runner *WebhookRunner) shutdown(srv *http.Server, handler *WebhookHandler, err error) error {
+    ctx, cancel := context.WithTimeout(context.Background(), runner.config.ShutdownTimeout)
+    defer cancel()
+
+    shutdownErr := srv.Shutdown(ctx)
+    if err == nil || err == http.ErrServerClosed {
+        err = shutdownErr
+    }
+
+    shutdownErr = handler.Shutdown(ctx)
+    if err == nil {
+        err = shutdownErr
+    }
+
+    if runner.config.DeleteOnShutdown {
+        _, shutdownErr = runner.client.DeleteWebhookCtx(ctx)
+        if err == nil {
+            err = shutdownErr
+        }
+    }
+
+    return err
+}
+
+func (runner *WebhookRunner) setWebhook(ctx context.Context) error {
+    req := &client.SetWebhookRequest{
+        Url: runner.config.Url,
+    }
+
+    if runner.config.SelfSigned {
+        certificate, err := client.NewFileInputFile(runner.config.CertFile)
+        if err != nil {
+            return err
+        }
+        req.Certificate = certificate
+    }
+
+    if runner.config.MaxConnections > 0 {
+        req.MaxConnections = client.OptionalInt(runner.config.MaxConnections)
+    }
+
+    if runner.config.AllowedUpdates != nil {
+        allowedUpdates := runner.config.AllowedUpdates
+        req.AllowedUpdates = &allowedUpdates
+    }
+
+    if runner.config.SecretToken != "" {
+        req.SecretToken = client.OptionalString(runner.config.SecretToken)
+    }
+
+    _, err := runner.client.SetWebhookCtx(ctx, req)
+
+    return err
+}
+
+// check compares the webhook state with the previous one and reports problems.
+func (runner *WebhookRunner) check(ctx context.Context) {
+    ticker := time.NewTicker(runner.config.CheckInterval)
+    defer ticker.Stop()
+
+    var lastErrorDate int64
+    var pendingUpdateCount int64
+    var changedUrl string
+
+    for {
+        select {
+        case <-ticker.C:
+            info, err := runner.client.GetWebhookInfoCtx(ctx)
+            if err != nil {
+                if ctx.Err() == nil {
+                    runner.problem(err, nil)
+                }
+                continue
+            }
+
+            if info.Url != runner.config.Url && (info.Url != changedUrl || runner.config.ReregisterOnChange) {
+                runner.problem(fmt.Errorf("%w: %q", ErrWebhookUrlChanged, info.Url), info)
+                changedUrl = info.Url
+            }
+
+            if info.Url != runner.config.Url && runner.config.ReregisterOnChange {
+                err = runner.setWebhook(ctx)
+                if err != nil && ctx.Err() == nil {
+                    runner.problem(err, info)
+                }
+            }
+
+            if info.Url == runner.config.Url {
+                changedUrl = ""
+            }
+
+            if info.LastErrorDate != nil && *info.LastErrorDate > lastErrorDate {
+                if lastErrorDate != 0 || time.Since(time.Unix(*info.LastErrorDate, 0)) < runner.config.CheckInterval {
+                    message := ""
+                    if info.LastErrorMessage != nil {
+                        message = *info.LastErrorMessage
+                    }
+                    runner.problem(fmt.Errorf("%w: %s", ErrWebhookDeliveryFailed, message), info)
+                }
+                lastErrorDate = *info.LastErrorDate
+            }
+
+            if info.PendingUpdateCount > 0 && pendingUpdateCount > 0 && info.PendingUpdateCount > pendingUpdateCount {
+                runner.problem(fmt.Errorf("%w: %d", ErrWebhookPendingGrowing, info.PendingUpdateCount), info)
+            }
+            pendingUpdateCount = info.PendingUpdateCount
+
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+func (runner *WebhookRunner) problem(err error, info *client.WebhookInfo) {
+    if runner.config.OnProblem != nil {
+        runner.config.OnProblem(err, info)
+    }
+}
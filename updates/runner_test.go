@@ -0,0 +1,171 @@
+package updates
+
+import (
+    "context"
+    "errors"
+    "net"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestWebhookRunnerListenError(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer listener.Close()
+
+    runner := NewWebhookRunner(server.Client(), WebhookConfig{
+        Url:        "http://" + listener.Addr().String() + "/webhook",
+        ListenAddr: listener.Addr().String(),
+    })
+
+    err = runner.Run(context.Background(), func(ctx context.Context, update *client.Update) {})
+    if err == nil {
+        t.Fatal("the busy port is used")
+    }
+
+    if len(server.CallsTo("setWebhook")) != 0 {
+        t.Fatal("the webhook is set for the failed server")
+    }
+}
+
+func TestWebhookRunner(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    addr := listener.Addr().String()
+    listener.Close()
+
+    runner := NewWebhookRunner(server.Client(), WebhookConfig{
+        Url:              "http://" + addr + "/webhook",
+        ListenAddr:       addr,
+        SecretToken:      "secret",
+        CheckInterval:    10 * time.Millisecond,
+        DeleteOnShutdown: true,
+    })
+
+    handled := make(chan *client.Update, 1)
+    ctx, cancel := context.WithCancel(context.Background())
+
+    runErr := make(chan error, 1)
+    go func() {
+        runErr <- runner.Run(ctx, func(ctx context.Context, update *client.Update) {
+            handled <- update
+        })
+    }()
+
+    for len(server.CallsTo("setWebhook")) == 0 {
+        time.Sleep(time.Millisecond)
+    }
+
+    user := grabottest.NewUser(1, "user")
+    update := server.AddMessage(grabottest.NewPrivateChat(user), user, "hello")
+
+    select {
+    case handledUpdate := <-handled:
+        if handledUpdate.UpdateId != update.UpdateId {
+            t.Fatalf("update #%d is handled, #%d expected", handledUpdate.UpdateId, update.UpdateId)
+        }
+
+    case <-time.After(5 * time.Second):
+        t.Fatal("the update isn't delivered")
+    }
+
+    cancel()
+
+    err = <-runErr
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(server.CallsTo("deleteWebhook")) != 1 {
+        t.Fatal("the webhook isn't deleted")
+    }
+
+    checks := len(server.CallsTo("getWebhookInfo"))
+    time.Sleep(50 * time.Millisecond)
+
+    if len(server.CallsTo("getWebhookInfo")) != checks {
+        t.Fatal("the webhook is checked after shutdown")
+    }
+}
+
+func TestWebhookRunnerUrlChange(t *testing.T) {
+    for _, reregister := range []bool{false, true} {
+        server := grabottest.NewServer()
+
+        listener, err := net.Listen("tcp", "127.0.0.1:0")
+        if err != nil {
+            t.Fatal(err)
+        }
+        addr := listener.Addr().String()
+        listener.Close()
+
+        problems := make(chan error, 100)
+
+        runner := NewWebhookRunner(server.Client(), WebhookConfig{
+            Url:                "http://" + addr + "/webhook",
+            ListenAddr:         addr,
+            CheckInterval:      10 * time.Millisecond,
+            ReregisterOnChange: reregister,
+            OnProblem: func(err error, info *client.WebhookInfo) {
+                problems <- err
+            },
+        })
+
+        ctx, cancel := context.WithCancel(context.Background())
+
+        runErr := make(chan error, 1)
+        go func() {
+            runErr <- runner.Run(ctx, func(ctx context.Context, update *client.Update) {})
+        }()
+
+        for len(server.CallsTo("setWebhook")) == 0 {
+            time.Sleep(time.Millisecond)
+        }
+
+        // another deployment takes the webhook
+        _, err = server.Client().SetWebhook(&client.SetWebhookRequest{Url: "http://127.0.0.1:1/other"})
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        time.Sleep(100 * time.Millisecond)
+
+        cancel()
+
+        err = <-runErr
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        server.Close()
+
+        setWebhookCalls := len(server.CallsTo("setWebhook"))
+
+        if !reregister && (len(problems) != 1 || setWebhookCalls != 2) {
+            t.Fatalf("the changed url should be reported once and kept: %d problems, %d setWebhook calls", len(problems), setWebhookCalls)
+        }
+
+        if reregister && setWebhookCalls != 3 {
+            t.Fatalf("the webhook should be registered again once: %d setWebhook calls", setWebhookCalls)
+        }
+
+        for len(problems) > 0 {
+            err = <-problems
+            if !errors.Is(err, ErrWebhookUrlChanged) {
+                t.Fatalf("%v, ErrWebhookUrlChanged expected", err)
+            }
+        }
+    }
+}
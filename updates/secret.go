@@ -0,0 +1,68 @@
+package updates
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "net"
+    "net/url"
+    "strings"
+)
+
+// TelegramNetworks are the networks Telegram sends webhook requests from.
+var TelegramNetworks = mustParseNetworks("149.154.160.0/20", "91.108.4.0/22")
+
+// NewSecretToken returns a random token for SetWebhookRequest.SecretToken and WithSecretToken.
+func NewSecretToken() (string, error) {
+    data := make([]byte, 32)
+
+    _, err := rand.Read(data)
+    if err != nil {
+        return "", err
+    }
+
+    return hex.EncodeToString(data), nil
+}
+
+// NewSecretPath returns a random path to hide the webhook behind, e.g. "/webhook/3f1c...".
+func NewSecretPath(prefix string) (string, error) {
+    secret, err := NewSecretToken()
+    if err != nil {
+        return "", err
+    }
+
+    return strings.TrimSuffix(prefix, "/") + "/" + secret, nil
+}
+
+// SecretWebhookUrl returns SetWebhookRequest.Url with a random path and the path for WithPath.
+// The random path is appended to the path of the base url: https://example.com/bot gives /bot/webhook/3f1c....
+func SecretWebhookUrl(baseUrl string) (string, string, error) {
+    webhookUrl, err := url.Parse(baseUrl)
+    if err != nil {
+        return "", "", err
+    }
+
+    path, err := NewSecretPath(strings.TrimSuffix(webhookUrl.Path, "/") + "/webhook")
+    if err != nil {
+        return "", "", err
+    }
+
+    webhookUrl.Path = path
+    webhookUrl.RawPath = ""
+
+    return webhookUrl.String(), path, nil
+}
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+    networks := []*net.IPNet{}
+
+    for _, cidr := range cidrs {
+        _, network, err := net.ParseCIDR(cidr)
+        if err != nil {
+            panic(err)
+        }
+
+        networks = append(networks, network)
+    }
+
+    return networks
+}
@@ -0,0 +1,38 @@
+package updates
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestSecretWebhookUrl(t *testing.T) {
+    tests := []struct {
+        baseUrl    string
+        pathPrefix string
+    }{
+        {"https://example.com:8443", "/webhook/"},
+        {"https://example.com/", "/webhook/"},
+        {"https://example.com/bot", "/bot/webhook/"},
+        {"https://example.com/bot/", "/bot/webhook/"},
+    }
+
+    for _, test := range tests {
+        webhookUrl, path, err := SecretWebhookUrl(test.baseUrl)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !strings.HasPrefix(path, test.pathPrefix) || len(path) != len(test.pathPrefix)+64 {
+            t.Errorf("%s: path %s, %s... expected", test.baseUrl, path, test.pathPrefix)
+        }
+
+        if webhookUrl != "https://"+strings.SplitN(strings.TrimPrefix(test.baseUrl, "https://"), "/", 2)[0]+path {
+            t.Errorf("%s: url %s, path %s", test.baseUrl, webhookUrl, path)
+        }
+    }
+
+    _, _, err := SecretWebhookUrl("://example.com")
+    if err == nil {
+        t.Error("an error expected for an invalid url")
+    }
+}
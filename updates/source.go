@@ -0,0 +1,135 @@
+package updates
+
+import (
+    "context"
+    "time"
+    "github.com/zelenin/grabot/client"
+)
+
+// UpdateSource delivers updates to the handler until ctx is done.
+// WebhookRunner, LongPollSource and AutoSource are update sources.
+type UpdateSource interface {
+    Run(ctx context.Context, updateHandler UpdateHandler) error
+}
+
+// LongPollSource is an UpdateSource on top of a LongPoller. Run handles updates one by one, RunParallel
+// handles them with a Dispatcher. In at-least-once mode updates are acknowledged as soon as the handler returns,
+// so don't pass Dispatcher.Handle to Run: it returns before the update is handled.
+type LongPollSource struct {
+    longPoller LongPoller
+    req        *client.GetUpdatesRequest
+    interval   time.Duration
+    onError    func(err error)
+}
+
+// NewLongPollSource polls with server-side long polling (30 seconds) unless options say otherwise.
+// onError receives polling errors, it may be nil.
+func NewLongPollSource(apiClient *client.Client, req *client.GetUpdatesRequest, onError func(err error), options ...LongPollerOption) *LongPollSource {
+    if req == nil {
+        req = &client.GetUpdatesRequest{}
+    }
+
+    options = append([]LongPollerOption{WithLongPollTimeout(30 * time.Second)}, options...)
+
+    return &LongPollSource{
+        longPoller: NewLongPoller(apiClient, options...),
+        req:        req,
+        interval:   time.Second,
+        onError:    onError,
+    }
+}
+
+func (source *LongPollSource) Run(ctx context.Context, updateHandler UpdateHandler) error {
+    return source.run(ctx, func(ctx context.Context, update *client.Update) {
+        updateHandler(ctx, update)
+        source.ack(update)
+    })
+}
+
+// RunParallel handles updates of the same chat in order and different chats in parallel, see NewDispatcher.
+// In at-least-once mode an update is acknowledged after its handler returns, not when it's queued.
+// On return it waits until the queued updates are handled.
+func (source *LongPollSource) RunParallel(ctx context.Context, updateHandler UpdateHandler, concurrency int) error {
+    dispatcher := NewDispatcher(updateHandler, concurrency, WithAfterHandle(source.ack))
+    defer dispatcher.Shutdown(context.Background())
+
+    return source.run(ctx, dispatcher.Handle)
+}
+
+func (source *LongPollSource) run(ctx context.Context, updateHandler UpdateHandler) error {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    updates, errs := source.longPoller.LongPoll(ctx, source.req, source.interval)
+
+    for {
+        select {
+        case update := <-updates:
+            updateHandler(ctx, update)
+
+        case err := <-errs:
+            if ctx.Err() != nil {
+                return nil
+            }
+
+            if source.onError != nil {
+                source.onError(err)
+            }
+
+        case <-ctx.Done():
+            return nil
+        }
+    }
+}
+
+func (source *LongPollSource) ack(update *client.Update) {
+    ackLongPoller, ok := source.longPoller.(AckLongPoller)
+    if !ok {
+        return
+    }
+
+    err := ackLongPoller.Ack(update)
+    if err != nil && source.onError != nil {
+        source.onError(err)
+    }
+}
+
+// AutoSource serves the webhook if config.Url is set and falls back to long polling otherwise,
+// so the same binary runs in production and locally.
+type AutoSource struct {
+    client  *client.Client
+    config  WebhookConfig
+    onError func(err error)
+    options []LongPollerOption
+}
+
+// NewAutoSource takes the webhook config and the long poller options. Problems of both modes are passed to onError.
+func NewAutoSource(apiClient *client.Client, config WebhookConfig, onError func(err error), options ...LongPollerOption) *AutoSource {
+    return &AutoSource{
+        client:  apiClient,
+        config:  config,
+        onError: onError,
+        options: options,
+    }
+}
+
+func (source *AutoSource) Run(ctx context.Context, updateHandler UpdateHandler) error {
+    if source.config.Url != "" {
+        config := source.config
+        if config.OnProblem == nil && source.onError != nil {
+            config.OnProblem = func(err error, info *client.WebhookInfo) {
+                source.onError(err)
+            }
+        }
+
+        return NewWebhookRunner(source.client, config).Run(ctx, updateHandler)
+    }
+
+    // getUpdates doesn't work while a webhook is set
+    _, err := source.client.DeleteWebhookCtx(ctx)
+    if err != nil {
+        return err
+    }
+
+    return NewLongPollSource(source.client, nil, source.onError, source.options...).Run(ctx, updateHandler)
+}
@@ -0,0 +1,64 @@
+package updates
+
+import (
+    "context"
+    "testing"
+    "time"
+    "github.com/zelenin/grabot/client"
+    "github.com/zelenin/grabot/grabottest"
+)
+
+func TestLongPollSourceRunParallelAcksAfterHandling(t *testing.T) {
+    server := grabottest.NewServer()
+    defer server.Close()
+
+    user := grabottest.NewUser(1, "user")
+    update := server.AddMessage(grabottest.NewPrivateChat(user), user, "hello")
+
+    offsetStore := NewMemoryOffsetStore()
+    source := NewLongPollSource(server.Client(), nil, nil, WithLongPollTimeout(time.Second), WithOffsetStore(offsetStore), WithAtLeastOnce())
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    handling := make(chan struct{})
+    release := make(chan struct{})
+
+    done := make(chan error)
+    go func() {
+        done <- source.RunParallel(ctx, func(ctx context.Context, update *client.Update) {
+            close(handling)
+            <-release
+        }, 10)
+    }()
+
+    select {
+    case <-handling:
+    case <-ctx.Done():
+        t.Fatal("the update isn't handled")
+    }
+
+    offset, _ := offsetStore.Load()
+    if offset != 0 {
+        t.Fatalf("the update is acknowledged before it's handled: offset %d", offset)
+    }
+
+    close(release)
+
+    for offset != update.UpdateId+1 {
+        select {
+        case <-ctx.Done():
+            t.Fatalf("the update isn't acknowledged: offset %d", offset)
+        case <-time.After(10 * time.Millisecond):
+        }
+
+        offset, _ = offsetStore.Load()
+    }
+
+    cancel()
+
+    err := <-done
+    if err != nil {
+        t.Fatal(err)
+    }
+}